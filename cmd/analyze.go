@@ -0,0 +1,96 @@
+package main
+
+import (
+	"data-insights/kit/common"
+	"data-insights/kit/file"
+	"data-insights/pkg"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+)
+
+// Analyze computes the metrics of the data files given as arguments, or of every file in FILE_DIR, and prints
+// them to stdout as JSON, keyed by file. Nothing is stored or sent and no API key is needed.
+func Analyze(args []string) {
+	flags := flag.NewFlagSet("analyze", flag.ExitOnError)
+	configFile := addSettingFlags(flags, "files")
+	_ = flags.Parse(args)
+
+	files := dataFiles(flags, *configFile)
+	envVariables, err := getEnvVariables(envRequirements{})
+	if err != nil {
+		exit(exitUsage, "error loading environment variables: %s", err)
+	}
+	source := pkg.DataSource(envVariables)
+	results := make(map[string]common.UserMetrics, len(files))
+	for _, fileSource := range files {
+		data, err := file.GetRawDataFromFile(source, fileSource)
+		if err != nil {
+			exit(exitFailure, "error while getting raw data from file %s: %s", fileSource, err)
+		}
+		results[fileSource] = pkg.KeyMetrics(envVariables, data)
+	}
+	printJSON(results)
+}
+
+// Insights computes the metrics and insights of the data files given as arguments, or of every file in FILE_DIR,
+// stores them as reports and prints the insights to stdout as JSON, keyed by file. Nothing is delivered.
+func Insights(args []string) {
+	flags := flag.NewFlagSet("insights", flag.ExitOnError)
+	configFile := addSettingFlags(flags, "files", "reports", "engine")
+	_ = flags.Parse(args)
+
+	files := dataFiles(flags, *configFile)
+	envVariables, err := getEnvVariables(envRequirements{})
+	if err != nil {
+		exit(exitUsage, "error loading environment variables: %s", err)
+	}
+	insightsClient, err := newInsightsClient(envVariables)
+	if err != nil {
+		exit(exitUsage, "error creating insights client: %s", err)
+	}
+
+	results := make(map[string]common.UserMetricsWithInsights, len(files))
+	for _, fileSource := range files {
+		insightsReport, _, err := pkg.GenerateReport(envVariables, fileSource, insightsClient)
+		if err != nil {
+			exit(exitFailure, "error processing file %s: %s", fileSource, err)
+		}
+		log.Printf("Report %s stored for the file %s", insightsReport.ID, fileSource)
+		results[fileSource] = insightsReport.Insights
+	}
+	printJSON(results)
+}
+
+// dataFiles applies the settings and returns the files given as arguments, or the data files selected in FILE_DIR.
+// Both can be local paths, s3:// locations or HTTP URLs.
+func dataFiles(flags *flag.FlagSet, configFile string) []string {
+	if err := applySettings(flags, configFile); err != nil {
+		exit(exitUsage, "%s", err)
+	}
+	if flags.NArg() > 0 {
+		return flags.Args()
+	}
+
+	envVariables, err := getEnvVariables(envRequirements{})
+	if err != nil {
+		exit(exitUsage, "error loading environment variables: %s", err)
+	}
+	if envVariables.FileDirectory == "" {
+		exit(exitUsage, "no data files given and FILE_DIR is not set")
+	}
+	files, err := pkg.DataSource(envVariables).List(envVariables.FileDirectory, pkg.WalkOptions(envVariables))
+	if err != nil {
+		exit(exitFailure, "error loading files: %s from directory: %s", err, envVariables.FileDirectory)
+	}
+	return files
+}
+
+func printJSON(value interface{}) {
+	content, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		exit(exitFailure, "error marshalling output: %s", err)
+	}
+	fmt.Println(string(content))
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bufio"
+	"data-insights/kit/ai"
+	"data-insights/kit/report"
+	"data-insights/pkg"
+	"flag"
+	"fmt"
+	"github.com/joho/godotenv"
+	"log"
+	"os"
+	"strings"
+)
+
+// Ask opens a multi-turn chat about a stored report. The model answers from the report's metrics and insights
+// and can query the report's raw data through the metrics tools. The latest report is used unless one is given.
+func Ask(args []string) {
+	_ = godotenv.Load()
+
+	flags := flag.NewFlagSet("ask", flag.ExitOnError)
+	reportID := flags.String("report", "", "ID of the report to ask about (defaults to the latest report)")
+	reportDir := flags.String("reports", getEnvOrDefault("REPORT_DIR", report.DefaultDir), "directory containing stored reports")
+	_ = flags.Parse(args)
+
+	apiKey := os.Getenv("OPENAI_API_KEY")
+	if apiKey == "" {
+		log.Fatalf("OPENAI_API_KEY environment variable is required to ask questions")
+	}
+
+	stored := loadReport(*reportDir, *reportID)
+
+	envVariables, err := getEnvVariables(envRequirements{})
+	if err != nil {
+		log.Fatalf("error loading environment variables: %s", err)
+	}
+	data, err := stored.SourceData(pkg.DataSource(envVariables))
+	if err != nil {
+		log.Fatalf("error loading raw data for report %s: %s", stored.ID, err)
+	}
+
+	openAiClient := newOpenAIClient(getEnvOrDefault("LLM_MODEL", string(ai.GPT4oMini)), getEnvOrDefault("LLM_URL", ai.OpenAIUrl), 0)
+	conversation := ai.NewReportConversation(openAiClient, apiKey, stored.Metrics, stored.Insights, data)
+	conversation.OnToolCall = func(call ai.ToolCall, _ string) {
+		fmt.Printf("  (querying %s %s)\n", call.Function.Name, call.Function.Arguments)
+	}
+
+	fmt.Printf("Asking about report %s (%s). Type \"exit\" to quit.\n", stored.ID, stored.SourceFile)
+	scanner := bufio.NewScanner(os.Stdin)
+	for {
+		fmt.Print("> ")
+		if !scanner.Scan() {
+			break
+		}
+		question := strings.TrimSpace(scanner.Text())
+		if question == "" {
+			continue
+		}
+		if question == "exit" || question == "quit" {
+			break
+		}
+
+		answer, err := conversation.Ask(question)
+		if err != nil {
+			log.Printf("error getting answer: %s", err)
+			continue
+		}
+		fmt.Println(answer)
+	}
+}
@@ -3,65 +3,365 @@ package main
 import (
 	"data-insights/kit/ai"
 	"data-insights/kit/common"
+	"data-insights/kit/config"
+	"data-insights/kit/email"
+	"data-insights/kit/file"
+	"data-insights/kit/ledger"
+	"data-insights/kit/report"
 	"data-insights/pkg"
+	"encoding/json"
 	"errors"
+	"flag"
 	"fmt"
-	"github.com/joho/godotenv"
-	"log"
-	"net/http"
+	"net/url"
 	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 )
 
-// Bootstrap initializes the application by loading environment variables and processing the files.
-// It logs a fatal error and terminates the application if any of the critical steps fail.
-func Bootstrap() {
-	envVariables, err := getEnvVariables()
+// Run processes every data file and delivers the insights, the whole pipeline. Settings come from flags,
+// environment variables and the config file, in that order. Files that have not changed since they were processed
+// are skipped unless --force is set. With --continue-on-error every file is processed even if one fails, and a
+// summary of the files is printed. With --dry-run emails and webhook payloads are written to disk instead of
+// sent. The exit code tells configuration errors and delivery failures apart from other failures.
+func Run(args []string) {
+	flags := flag.NewFlagSet("run", flag.ExitOnError)
+	configFile := addSettingFlags(flags, "files", "reports", "engine", "channels", "recipients", "to", "templates",
+		"theme", "formats", "output", "charts", "pdf", "pdf-dir", "digest", "outbox", "ledger", "concurrency", "continue-on-error", "operator")
+	force := flags.Bool("force", false, "process every file, also those that have not changed since they were processed")
+	dryRun := flags.Bool("dry-run", false, "write emails and webhook payloads to disk instead of sending them")
+	dryRunDir := flags.String("dry-run-dir", email.DryRunDir, "directory for dry run output")
+	_ = flags.Parse(args)
+
+	if err := applySettings(flags, *configFile); err != nil {
+		exit(exitUsage, "%s", err)
+	}
+	envVariables, err := getEnvVariables(envRequirements{Files: true, Delivery: true, DryRun: *dryRun})
 	if err != nil {
-		log.Fatalf("error loading environment variables: %s", err)
+		exit(exitUsage, "error loading environment variables: %s", err)
 	}
+	envVariables.Force = *force
+	envVariables.DryRun = *dryRun
+	envVariables.DryRunDirectory = *dryRunDir
 
-	openAiClient := ai.NewOpenAIClient(ai.GPT4oMini, ai.OpenAIUrl, ai.OpenAIMaxTokens, ai.OpenAISenderRole, &http.Client{})
-	err = pkg.ProcessFiles(envVariables, openAiClient)
+	insightsClient, err := newInsightsClient(envVariables)
 	if err != nil {
-		log.Fatalf("error processing files: %s", err)
+		exit(exitUsage, "error creating insights client: %s", err)
+	}
+
+	summary, err := pkg.ProcessFiles(envVariables, insightsClient)
+	if envVariables.ContinueOnError && len(summary.Results) > 0 {
+		fmt.Print(summary.Table())
+	}
+	var deliveryErr *pkg.DeliveryError
+	if errors.As(err, &deliveryErr) {
+		exit(exitDeliveryFailure, "error processing files: %s", err)
+	}
+	if err != nil {
+		exit(exitFailure, "error processing files: %s", err)
+	}
+}
+
+// newInsightsClient returns the insights client for the configured engine. The default engine asks OpenAI and
+// falls back to rule-based insights when the request fails or no API key is set.
+func newInsightsClient(envVariables common.EnvVariables) (ai.Client, error) {
+	openAiClient := newOpenAIClient(envVariables.LlmModel, envVariables.LlmURL, envVariables.LlmRequestsPerMin)
+
+	switch ai.Engine(envVariables.InsightEngine) {
+	case ai.EngineAuto, "":
+		return ai.NewFallbackClient(openAiClient, ai.NewRuleBasedClient()), nil
+	case ai.EngineLLM:
+		if envVariables.ApiKey == "" {
+			return nil, errors.New("OPENAI_API_KEY environment variable is required for the llm insight engine")
+		}
+		return openAiClient, nil
+	case ai.EngineRules:
+		return ai.NewRuleBasedClient(), nil
+	default:
+		return nil, fmt.Errorf("unknown insight engine: %s", envVariables.InsightEngine)
 	}
 }
 
-// getEnvVariables loads and validates the required environment variables from a `.env` file.
+// newOpenAIClient returns an OpenAI client for the model and chat completions URL. Its requests are limited to
+// requestsPerMinute, shared by every file processed at the same time, unless it is 0.
+func newOpenAIClient(model, url string, requestsPerMinute int) ai.OpenAIClient {
+	return ai.NewOpenAIClient(ai.AIModel(model), url, ai.OpenAIMaxTokens, ai.OpenAISenderRole, ai.NewHTTPClient(requestsPerMinute))
+}
+
+// envRequirements selects the environment variables that a command requires.
+type envRequirements struct {
+	Files    bool // FILE_DIR, for commands that process the data files
+	Delivery bool // the email settings, unless a CHANNELS_FILE is set
+	DryRun   bool // nothing is sent, so the SMTP server and password are not needed
+}
+
+// getEnvVariables reads the settings from the environment and validates the ones the command requires.
 // It uses a map to streamline the process of checking for each variable.
-// Returns a populated EnvVariables struct if all required variables are set, or an error if any are missing.
-func getEnvVariables() (common.EnvVariables, error) {
+// OPENAI_API_KEY, INSIGHT_ENGINE and REPORT_DIR are optional, since insights can be generated without the LLM.
+// The email settings are only required for delivery when no channels are configured, in a CHANNELS_FILE or the
+// config file, since email is then always a channel; otherwise they are checked when a channel uses email.
+// EMAIL_TO and RECIPIENT_NAME are only required when no recipients are configured, and EMAIL_FROM_PASS only when
+// SMTP_AUTH is not "none". SMTP_HOST, SMTP_PORT and EMAIL_FROM_PASS are not required for a dry run, since nothing
+// is sent. SMTP_PORT must be a valid port number when set. CONFIG_FILE, LLM_MODEL, LLM_URL, MIN_DATA_POINTS, SMTP_SECURITY, SMTP_AUTH, SMTP_CA_FILE,
+// the webhook URLs, CHART_FORMAT, PDF_ATTACHMENT, PDF_DIR, OUTPUT_FORMATS, OUTPUT_PATH, TEMPLATE_DIR, THEME_FILE,
+// DIGEST, LEDGER_FILE, CONCURRENCY, LLM_REQUESTS_PER_MINUTE, CONTINUE_ON_ERROR, OPERATOR_EMAIL, the OUTBOX_
+// variables and the FILE_ variables that filter the data files are optional.
+// Returns a populated EnvVariables struct, or an error listing every missing or invalid variable.
+func getEnvVariables(required envRequirements) (common.EnvVariables, error) {
+	dryRun := required.DryRun
 
-	if err := godotenv.Load(); err != nil {
-		return common.EnvVariables{}, errors.New("no .env file found")
+	vars := map[string]string{}
+	if required.Files {
+		vars["FILE_DIR"] = ""
 	}
 
-	vars := map[string]string{
-		"FILE_DIR":        "",
-		"OPENAI_API_KEY":  "",
-		"EMAIL_FROM":      "",
-		"EMAIL_FROM_PASS": "",
-		"EMAIL_TO":        "",
-		"RECIPIENT_NAME":  "",
-		"SMTP_HOST":       "",
-		"SMTP_PORT":       "",
+	// The channels and recipients of a YAML or TOML config file replace the files
+	var configured config.Config
+	if configFile := os.Getenv("CONFIG_FILE"); config.IsConfigFile(configFile) {
+		var err error
+		if configured, err = config.Load(configFile); err != nil {
+			return common.EnvVariables{}, err
+		}
 	}
 
+	smtpAuth := os.Getenv("SMTP_AUTH")
+	recipientsFile := os.Getenv("RECIPIENTS_FILE")
+	channelsFile := os.Getenv("CHANNELS_FILE")
+	if required.Delivery && channelsFile == "" && len(configured.Channels) == 0 {
+		vars["EMAIL_FROM"] = ""
+		if !dryRun {
+			vars["SMTP_HOST"] = ""
+			vars["SMTP_PORT"] = ""
+		}
+
+		// No password is needed when SMTP authentication is disabled or nothing is sent
+		if smtpAuth != "none" && !dryRun {
+			vars["EMAIL_FROM_PASS"] = ""
+		}
+
+		// A recipients file replaces the single EMAIL_TO recipient
+		if recipientsFile == "" && configured.Recipients == nil {
+			vars["EMAIL_TO"] = ""
+			vars["RECIPIENT_NAME"] = ""
+		}
+	}
+
+	var problems []string
+	pdfAttachment, err := getBoolVariable("PDF_ATTACHMENT")
+	if err != nil {
+		problems = append(problems, err.Error())
+	}
+	digest, err := getBoolVariable("DIGEST")
+	if err != nil {
+		problems = append(problems, err.Error())
+	}
+	continueOnError, err := getBoolVariable("CONTINUE_ON_ERROR")
+	if err != nil {
+		problems = append(problems, err.Error())
+	}
+	fileHidden, err := getBoolVariable("FILE_HIDDEN")
+	if err != nil {
+		problems = append(problems, err.Error())
+	}
+	fileMaxDepth, err := getPositiveIntVariable("FILE_MAX_DEPTH", 0)
+	if err != nil {
+		problems = append(problems, err.Error())
+	}
+	fileModifiedSince, err := getFileFilterVariables()
+	if err != nil {
+		problems = append(problems, err.Error())
+	}
+	httpHeaders, err := getSourceVariables()
+	if err != nil {
+		problems = append(problems, err.Error())
+	}
+	minDataPoints, err := getPositiveIntVariable("MIN_DATA_POINTS", 0)
+	if err != nil {
+		problems = append(problems, err.Error())
+	}
+	concurrency, err := getPositiveIntVariable("CONCURRENCY", 1)
+	if err != nil {
+		problems = append(problems, err.Error())
+	}
+	llmRequestsPerMin, err := getPositiveIntVariable("LLM_REQUESTS_PER_MINUTE", 0)
+	if err != nil {
+		problems = append(problems, err.Error())
+	}
+	if value := os.Getenv("SMTP_PORT"); value != "" {
+		if port, err := strconv.Atoi(value); err != nil || port < 1 || port > 65535 {
+			problems = append(problems, fmt.Sprintf("invalid SMTP_PORT value %q: must be a port number between 1 and 65535", value))
+		}
+	}
+	outboxMaxAttempts, outboxBackoff, err := getOutboxVariables()
+	if err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	var missing []string
 	for key := range vars {
-		vars[key] = os.Getenv(key)
-		if vars[key] == "" {
-			return common.EnvVariables{}, fmt.Errorf("%s environment variable is required", key)
+		if os.Getenv(key) == "" {
+			missing = append(missing, key)
 		}
 	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		problems = append([]string{fmt.Sprintf("%s environment variables are required", strings.Join(missing, ", "))}, problems...)
+	}
+	if len(problems) > 0 {
+		return common.EnvVariables{}, errors.New(strings.Join(problems, "; "))
+	}
 
 	return common.EnvVariables{
-		FileDirectory: vars["FILE_DIR"],
-		ApiKey:        vars["OPENAI_API_KEY"],
-		EmailFrom:     vars["EMAIL_FROM"],
-		EmailPass:     vars["EMAIL_FROM_PASS"],
-		EmailTo:       vars["EMAIL_TO"],
-		RecipientName: vars["RECIPIENT_NAME"],
-		SmtpHost:      vars["SMTP_HOST"],
-		SmtpPort:      vars["SMTP_PORT"],
+		ConfigFile:        os.Getenv("CONFIG_FILE"),
+		FileDirectory:     os.Getenv("FILE_DIR"),
+		FileInclude:       os.Getenv("FILE_INCLUDE"),
+		FileExclude:       os.Getenv("FILE_EXCLUDE"),
+		FileExtensions:    getEnvOrDefault("FILE_EXTENSIONS", file.DefaultExtensions),
+		FileMaxDepth:      fileMaxDepth,
+		FileSymlinks:      os.Getenv("FILE_SYMLINKS"),
+		FileHidden:        fileHidden,
+		FileModifiedSince: fileModifiedSince,
+		S3Endpoint:        os.Getenv("S3_ENDPOINT"),
+		S3Region:          getEnvOrDefault("S3_REGION", getEnvOrDefault("AWS_REGION", file.DefaultS3Region)),
+		S3AccessKeyID:     getEnvOrDefault("S3_ACCESS_KEY_ID", os.Getenv("AWS_ACCESS_KEY_ID")),
+		S3SecretAccessKey: getEnvOrDefault("S3_SECRET_ACCESS_KEY", os.Getenv("AWS_SECRET_ACCESS_KEY")),
+		HTTPHeaders:       httpHeaders,
+		ReportDirectory:   getEnvOrDefault("REPORT_DIR", report.DefaultDir),
+		ApiKey:            os.Getenv("OPENAI_API_KEY"),
+		InsightEngine:     os.Getenv("INSIGHT_ENGINE"),
+		LlmModel:          getEnvOrDefault("LLM_MODEL", string(ai.GPT4oMini)),
+		LlmURL:            getEnvOrDefault("LLM_URL", ai.OpenAIUrl),
+		MinDataPoints:     minDataPoints,
+		EmailFrom:         os.Getenv("EMAIL_FROM"),
+		EmailPass:         os.Getenv("EMAIL_FROM_PASS"),
+		EmailTo:           os.Getenv("EMAIL_TO"),
+		RecipientName:     os.Getenv("RECIPIENT_NAME"),
+		RecipientsFile:    recipientsFile,
+		SmtpHost:          os.Getenv("SMTP_HOST"),
+		SmtpPort:          os.Getenv("SMTP_PORT"),
+		SmtpSecurity:      os.Getenv("SMTP_SECURITY"),
+		SmtpAuth:          smtpAuth,
+		SmtpCAFile:        os.Getenv("SMTP_CA_FILE"),
+		ChannelsFile:      channelsFile,
+		SlackWebhookURL:   os.Getenv("SLACK_WEBHOOK_URL"),
+		TeamsWebhookURL:   os.Getenv("TEAMS_WEBHOOK_URL"),
+		WebhookURL:        os.Getenv("WEBHOOK_URL"),
+		ChartFormat:       os.Getenv("CHART_FORMAT"),
+		PdfAttachment:     pdfAttachment,
+		PdfDirectory:      os.Getenv("PDF_DIR"),
+		OutputFormats:     os.Getenv("OUTPUT_FORMATS"),
+		OutputPath:        os.Getenv("OUTPUT_PATH"),
+		TemplateDirectory: getEnvOrDefault("TEMPLATE_DIR", email.TemplateDir),
+		ThemeFile:         os.Getenv("THEME_FILE"),
+		Digest:            digest,
+		OutboxDirectory:   getEnvOrDefault("OUTBOX_DIR", email.OutboxDir),
+		OutboxMaxAttempts: outboxMaxAttempts,
+		OutboxBackoff:     outboxBackoff,
+		LedgerFile:        getEnvOrDefault("LEDGER_FILE", ledger.DefaultPath),
+		Concurrency:       concurrency,
+		LlmRequestsPerMin: llmRequestsPerMin,
+		ContinueOnError:   continueOnError,
+		OperatorEmail:     os.Getenv("OPERATOR_EMAIL"),
 	}, nil
 }
+
+// getFileFilterVariables checks the patterns in FILE_INCLUDE and FILE_EXCLUDE and the policy in FILE_SYMLINKS,
+// and parses FILE_MODIFIED_SINCE, where a duration is relative to now.
+func getFileFilterVariables() (time.Time, error) {
+	var problems []string
+	for _, key := range []string{"FILE_INCLUDE", "FILE_EXCLUDE"} {
+		if _, err := file.ParsePatterns(os.Getenv(key)); err != nil {
+			problems = append(problems, fmt.Sprintf("invalid %s value: %v", key, err))
+		}
+	}
+	if _, err := file.ParseSymlinkPolicy(os.Getenv("FILE_SYMLINKS")); err != nil {
+		problems = append(problems, fmt.Sprintf("invalid FILE_SYMLINKS value: %v", err))
+	}
+	modifiedSince, err := file.ParseModifiedSince(os.Getenv("FILE_MODIFIED_SINCE"), time.Now())
+	if err != nil {
+		problems = append(problems, fmt.Sprintf("invalid FILE_MODIFIED_SINCE value: %v", err))
+	}
+	if len(problems) > 0 {
+		return time.Time{}, errors.New(strings.Join(problems, "; "))
+	}
+	return modifiedSince, nil
+}
+
+// getSourceVariables checks the S3_ENDPOINT URL and that the S3 credentials are set together, and parses
+// HTTP_HEADERS, a JSON object of header names and values.
+func getSourceVariables() (map[string]string, error) {
+	var problems []string
+	if value := os.Getenv("S3_ENDPOINT"); value != "" {
+		if endpoint, err := url.Parse(value); err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+			problems = append(problems, fmt.Sprintf("invalid S3_ENDPOINT value %q: must be an http or https URL", value))
+		}
+	}
+	accessKeyID := getEnvOrDefault("S3_ACCESS_KEY_ID", os.Getenv("AWS_ACCESS_KEY_ID"))
+	secretAccessKey := getEnvOrDefault("S3_SECRET_ACCESS_KEY", os.Getenv("AWS_SECRET_ACCESS_KEY"))
+	if (accessKeyID == "") != (secretAccessKey == "") {
+		problems = append(problems, "S3_ACCESS_KEY_ID and S3_SECRET_ACCESS_KEY must be set together")
+	}
+	var headers map[string]string
+	if value := os.Getenv("HTTP_HEADERS"); value != "" {
+		if err := json.Unmarshal([]byte(value), &headers); err != nil {
+			// The value is not echoed, it usually holds credentials
+			problems = append(problems, fmt.Sprintf("invalid HTTP_HEADERS value: must be a JSON object of header names and values: %v", err))
+		}
+	}
+	if len(problems) > 0 {
+		return nil, errors.New(strings.Join(problems, "; "))
+	}
+	return headers, nil
+}
+
+// getOutboxVariables parses OUTBOX_MAX_ATTEMPTS and OUTBOX_BACKOFF, falling back to the defaults when unset.
+func getOutboxVariables() (int, time.Duration, error) {
+	maxAttempts, err := getPositiveIntVariable("OUTBOX_MAX_ATTEMPTS", email.DefaultMaxAttempts)
+	if err != nil {
+		return 0, 0, err
+	}
+	backoff := email.DefaultRetryBackoff
+	if value := os.Getenv("OUTBOX_BACKOFF"); value != "" {
+		if backoff, err = time.ParseDuration(value); err != nil || backoff < 0 {
+			return 0, 0, fmt.Errorf("invalid OUTBOX_BACKOFF value %q: must be a duration such as 5s", value)
+		}
+	}
+	return maxAttempts, backoff, nil
+}
+
+// getBoolVariable parses the boolean environment variable key, which is false when unset.
+func getBoolVariable(key string) (bool, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return false, nil
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s value %q: %v", key, value, err)
+	}
+	return parsed, nil
+}
+
+// getPositiveIntVariable parses the environment variable key as a positive integer, or returns fallback if it is
+// not set.
+func getPositiveIntVariable(key string, fallback int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed < 1 {
+		return 0, fmt.Errorf("invalid %s value %q: must be a positive integer", key, value)
+	}
+	return parsed, nil
+}
+
+// getEnvOrDefault returns the value of the environment variable key, or fallback if it is not set.
+func getEnvOrDefault(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
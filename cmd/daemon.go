@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"data-insights/kit/common"
+	"data-insights/kit/email"
+	"data-insights/kit/schedule"
+	"data-insights/pkg"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+)
+
+// Daemon runs the jobs of the jobs file on their cron schedules until it receives SIGINT or SIGTERM, and then
+// waits for the running job to finish. Each job runs the whole pipeline with its own config file; jobs run one at
+// a time and runs missed while the daemon was stopped are caught up when it starts.
+func Daemon(args []string) {
+	flags := flag.NewFlagSet("daemon", flag.ExitOnError)
+	jobsFile := flags.String("jobs", getEnvOrDefault("SCHEDULE_FILE", schedule.DefaultJobsFile), "jobs file with the cron schedules")
+	stateFile := flags.String("state", getEnvOrDefault("SCHEDULE_STATE_FILE", schedule.DefaultStateFile), "file recording the last run of every job")
+	dryRun := flags.Bool("dry-run", false, "write emails and webhook payloads to disk instead of sending them")
+	dryRunDir := flags.String("dry-run-dir", email.DryRunDir, "directory for dry run output")
+	_ = flags.Parse(args)
+
+	jobs, err := schedule.LoadJobs(*jobsFile)
+	if err != nil {
+		exit(exitUsage, "error loading jobs: %s", err)
+	}
+	// Check every job's configuration up front rather than at its first run
+	var problems []string
+	for _, job := range jobs.Jobs {
+		err := withEnvironment(func() error {
+			_, err := jobEnvVariables(job, *dryRun)
+			return err
+		})
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("job %s: %s", job.Name, strings.ReplaceAll(err.Error(), "\n", "\n    ")))
+		}
+	}
+	if len(problems) > 0 {
+		exit(exitUsage, "invalid job configuration:\n  - %s", strings.Join(problems, "\n  - "))
+	}
+
+	state, err := schedule.LoadState(*stateFile)
+	if err != nil {
+		exit(exitFailure, "error loading scheduler state: %s", err)
+	}
+	scheduler, err := schedule.NewScheduler(jobs.Jobs, state, func(job schedule.Job) error {
+		return runJob(job, *dryRun, *dryRunDir)
+	})
+	if err != nil {
+		exit(exitUsage, "error creating scheduler: %s", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		// A second signal terminates the daemon without waiting for the running job
+		stop()
+		log.Printf("Shutting down once the running job, if any, has finished")
+	}()
+
+	log.Printf("Scheduler started with %d jobs", len(jobs.Jobs))
+	if err := scheduler.Start(ctx); err != nil {
+		exit(exitFailure, "scheduler failed: %s", err)
+	}
+	log.Printf("Scheduler stopped")
+}
+
+// runJob runs the pipeline with the job's settings.
+func runJob(job schedule.Job, dryRun bool, dryRunDir string) error {
+	return withEnvironment(func() error {
+		envVariables, err := jobEnvVariables(job, dryRun)
+		if err != nil {
+			return err
+		}
+		envVariables.DryRunDirectory = dryRunDir
+		insightsClient, err := newInsightsClient(envVariables)
+		if err != nil {
+			return err
+		}
+		summary, err := pkg.ProcessFiles(envVariables, insightsClient)
+		if envVariables.ContinueOnError && len(summary.Results) > 0 {
+			log.Printf("Summary of job %s:\n%s", job.Name, summary.Table())
+		}
+		return err
+	})
+}
+
+// jobEnvVariables loads the job's config file into the environment and reads the settings of a run from it.
+func jobEnvVariables(job schedule.Job, dryRun bool) (common.EnvVariables, error) {
+	configFile, explicit := job.Config, job.Config != ""
+	if !explicit {
+		configFile = getEnvOrDefault("CONFIG_FILE", defaultConfigFile)
+	}
+	if err := loadSettingsFile(configFile, explicit); err != nil {
+		return common.EnvVariables{}, err
+	}
+	envVariables, err := getEnvVariables(envRequirements{Files: true, Delivery: true, DryRun: dryRun})
+	if err != nil {
+		return common.EnvVariables{}, err
+	}
+	envVariables.DryRun = dryRun
+	return envVariables, nil
+}
+
+// withEnvironment calls fn and then restores the environment, so that the settings one job loads from its config
+// file never leak into the next.
+func withEnvironment(fn func() error) error {
+	environment := os.Environ()
+	defer func() {
+		os.Clearenv()
+		for _, variable := range environment {
+			if key, value, ok := strings.Cut(variable, "="); ok {
+				_ = os.Setenv(key, value)
+			}
+		}
+	}()
+	return fn()
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"data-insights/kit/ai"
+	"data-insights/kit/eval"
+	"flag"
+	"fmt"
+	"github.com/joho/godotenv"
+	"log"
+	"os"
+)
+
+// Eval replays the evaluation fixtures through the LLM, or through recorded responses when offline, scores
+// the outputs and diffs the scores against the stored baseline. It exits with a non-zero status on regressions,
+// including a lower score, and when there is no baseline, so that it can gate CI.
+func Eval(args []string) {
+	flags := flag.NewFlagSet("eval", flag.ExitOnError)
+	fixtureDir := flags.String("fixtures", eval.FixtureDir, "directory containing UserMetrics fixtures")
+	recordedDir := flags.String("recorded", eval.RecordedDir, "directory containing recorded LLM responses")
+	baselinePath := flags.String("baseline", eval.BaselineFile, "baseline report to diff against")
+	live := flags.Bool("live", false, "call the LLM instead of replaying recorded responses")
+	record := flags.Bool("record", false, "store live responses in the recorded directory (implies -live)")
+	updateBaseline := flags.Bool("update-baseline", false, "overwrite the baseline with the current report")
+	_ = flags.Parse(args)
+
+	fixtures, err := eval.LoadFixtures(*fixtureDir)
+	if err != nil {
+		log.Fatalf("error loading fixtures: %s", err)
+	}
+	if len(fixtures) == 0 {
+		log.Fatalf("no fixtures found in %s", *fixtureDir)
+	}
+
+	var generator eval.Generator = eval.NewRecordedGenerator(*recordedDir)
+	if *live || *record {
+		_ = godotenv.Load()
+		apiKey := os.Getenv("OPENAI_API_KEY")
+		if apiKey == "" {
+			log.Fatalf("OPENAI_API_KEY environment variable is required for live evaluation")
+		}
+		var recordDir string
+		if *record {
+			recordDir = *recordedDir
+		}
+		openAiClient := newOpenAIClient(getEnvOrDefault("LLM_MODEL", string(ai.GPT4oMini)), getEnvOrDefault("LLM_URL", ai.OpenAIUrl), 0)
+		generator = eval.NewLiveGenerator(openAiClient, apiKey, recordDir)
+	}
+
+	report := eval.Evaluate(fixtures, generator, eval.DefaultLimits())
+	printReport(report)
+
+	if *updateBaseline {
+		if err := eval.SaveReport(*baselinePath, report); err != nil {
+			log.Fatalf("error saving baseline: %s", err)
+		}
+		log.Printf("Baseline written to %s", *baselinePath)
+		return
+	}
+
+	baseline, err := eval.LoadReport(*baselinePath)
+	if err != nil {
+		exit(exitFailure, "no baseline to compare against, create one with -update-baseline: %s", err)
+	}
+	comparison := eval.Compare(baseline, report)
+	fmt.Printf("\nBaseline score: %.2f, current score: %.2f\n", baseline.Score, report.Score)
+	for _, diff := range comparison.Improvements {
+		fmt.Printf("  improved:  %s %s\n", diff.Fixture, diff.Check)
+	}
+	for _, diff := range comparison.Regressions {
+		fmt.Printf("  regressed: %s %s\n", diff.Fixture, diff.Check)
+	}
+	if comparison.Regressed() {
+		exit(exitFailure, "insight quality regressed against %s", *baselinePath)
+	}
+}
+
+func printReport(report eval.Report) {
+	for _, result := range report.Results {
+		fmt.Printf("%s: %.2f\n", result.Fixture, result.Score)
+		if result.Error != "" {
+			fmt.Printf("  error: %s\n", result.Error)
+		}
+		for _, check := range result.Checks {
+			status := "PASS"
+			if !check.Passed {
+				status = "FAIL"
+			}
+			fmt.Printf("  %s %s", status, check.Name)
+			if check.Detail != "" {
+				fmt.Printf(": %s", check.Detail)
+			}
+			fmt.Println()
+		}
+	}
+	fmt.Printf("Overall score: %.2f\n", report.Score)
+}
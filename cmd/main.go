@@ -1,5 +1,82 @@
 package main
 
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// Exit codes of the commands.
+const (
+	exitOK              = 0
+	exitFailure         = 1 // processing failed
+	exitUsage           = 2 // invalid flags or configuration
+	exitDeliveryFailure = 3 // insights were generated and stored, but a channel failed
+)
+
+const usage = `Usage: data-insights [command] [flags]
+
+Commands:
+  run        process every data file and deliver the insights (default)
+  analyze    compute the metrics of data files and print them as JSON
+  insights   compute metrics and insights, store the reports and print the insights as JSON
+  render     write a stored report to disk as HTML, Markdown, JSON or PDF
+  send       deliver a stored report to the configured channels
+  validate   check the configuration without processing or sending anything
+  ask        ask follow-up questions about a stored report
+  eval       evaluate insight quality against the fixtures
+  preview    serve the rendered report with live template reload
+  resend     send or replay the messages queued in the outbox
+  daemon     run the scheduled jobs until stopped
+  watch      process new data files as they arrive
+
+Run "data-insights <command> -h" for the flags of a command. Flags override environment variables, which
+override the config file (.env by default).
+`
+
 func main() {
-	Bootstrap()
+	args := os.Args[1:]
+	command := "run"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	}
+
+	switch command {
+	case "run":
+		Run(args)
+	case "analyze":
+		Analyze(args)
+	case "insights":
+		Insights(args)
+	case "render":
+		Render(args)
+	case "send":
+		Send(args)
+	case "validate":
+		Validate(args)
+	case "eval":
+		Eval(args)
+	case "ask":
+		Ask(args)
+	case "resend":
+		Resend(args)
+	case "preview":
+		Preview(args)
+	case "daemon":
+		Daemon(args)
+	case "watch":
+		Watch(args)
+	case "help":
+		fmt.Print(usage)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", command, usage)
+		os.Exit(exitUsage)
+	}
+}
+
+// exit logs the message and terminates the application with the given exit code.
+func exit(code int, format string, args ...interface{}) {
+	log.Printf(format, args...)
+	os.Exit(code)
 }
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"data-insights/kit/chart"
+	"data-insights/kit/email"
+	"data-insights/kit/preview"
+	"data-insights/kit/report"
+	"flag"
+	"github.com/joho/godotenv"
+	"log"
+	"net/http"
+)
+
+// Preview serves the rendered report on localhost and reloads it when the templates, theme or insights file
+// change. The report is rendered from a saved UserMetricsWithInsights JSON file, or from a stored report, with
+// charts, when no insights file is given.
+func Preview(args []string) {
+	_ = godotenv.Load()
+
+	flags := flag.NewFlagSet("preview", flag.ExitOnError)
+	insightsFile := flags.String("insights", "", "saved UserMetricsWithInsights JSON file to render")
+	reportID := flags.String("report", "", "ID of the stored report to render (defaults to the latest report)")
+	reportDir := flags.String("reports", getEnvOrDefault("REPORT_DIR", report.DefaultDir), "directory containing stored reports")
+	templateDir := flags.String("templates", getEnvOrDefault("TEMPLATE_DIR", email.TemplateDir), "template directory")
+	themeFile := flags.String("theme", getEnvOrDefault("THEME_FILE", ""), "theme file")
+	addr := flags.String("addr", preview.DefaultAddr, "address to serve the preview on")
+	_ = flags.Parse(args)
+
+	var server *preview.Server
+	if *insightsFile != "" {
+		server = preview.NewServer(*templateDir, *themeFile, *insightsFile)
+	} else {
+		stored := loadReport(*reportDir, *reportID)
+		charts, err := chart.ReportCharts(stored.Metrics, nil, chart.FormatPNG)
+		if err != nil {
+			log.Fatalf("error rendering charts: %s", err)
+		}
+		server = preview.NewReportServer(*templateDir, *themeFile, stored.Insights, stored.ToolCalls, charts)
+	}
+
+	log.Printf("Serving the report preview on http://%s", *addr)
+	if err := http.ListenAndServe(*addr, server.Handler()); err != nil {
+		log.Fatalf("error serving preview: %s", err)
+	}
+}
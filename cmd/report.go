@@ -0,0 +1,83 @@
+package main
+
+import (
+	"data-insights/kit/email"
+	"data-insights/kit/output"
+	"data-insights/kit/report"
+	"data-insights/pkg"
+	"errors"
+	"flag"
+)
+
+// Render writes a stored report to disk in the configured output formats, HTML when none are configured, and as
+// a PDF when a PDF directory is set. Nothing is recomputed. The latest report is used unless one is given.
+func Render(args []string) {
+	flags := flag.NewFlagSet("render", flag.ExitOnError)
+	configFile := addSettingFlags(flags, "reports", "templates", "theme", "formats", "output", "charts", "pdf-dir")
+	reportID := flags.String("report", "", "ID of the report to render (defaults to the latest report)")
+	_ = flags.Parse(args)
+
+	if err := applySettings(flags, *configFile); err != nil {
+		exit(exitUsage, "%s", err)
+	}
+	envVariables, err := getEnvVariables(envRequirements{})
+	if err != nil {
+		exit(exitUsage, "error loading environment variables: %s", err)
+	}
+	if envVariables.OutputFormats == "" && envVariables.PdfDirectory == "" {
+		envVariables.OutputFormats = string(output.FormatHTML)
+	}
+
+	stored := loadReport(envVariables.ReportDirectory, *reportID)
+	if err := pkg.RenderReport(envVariables, stored); err != nil {
+		exit(exitFailure, "error rendering report %s: %s", stored.ID, err)
+	}
+}
+
+// Send delivers a stored report to every configured channel without recomputing anything. The latest report is
+// used unless one is given.
+func Send(args []string) {
+	flags := flag.NewFlagSet("send", flag.ExitOnError)
+	configFile := addSettingFlags(flags, "reports", "channels", "recipients", "to", "templates", "theme", "charts",
+		"pdf", "pdf-dir", "outbox")
+	reportID := flags.String("report", "", "ID of the report to send (defaults to the latest report)")
+	dryRun := flags.Bool("dry-run", false, "write emails and webhook payloads to disk instead of sending them")
+	dryRunDir := flags.String("dry-run-dir", email.DryRunDir, "directory for dry run output")
+	_ = flags.Parse(args)
+
+	if err := applySettings(flags, *configFile); err != nil {
+		exit(exitUsage, "%s", err)
+	}
+	envVariables, err := getEnvVariables(envRequirements{Delivery: true, DryRun: *dryRun})
+	if err != nil {
+		exit(exitUsage, "error loading environment variables: %s", err)
+	}
+	envVariables.DryRun = *dryRun
+	envVariables.DryRunDirectory = *dryRunDir
+
+	stored := loadReport(envVariables.ReportDirectory, *reportID)
+	err = pkg.SendReport(envVariables, stored)
+	var deliveryErr *pkg.DeliveryError
+	if errors.As(err, &deliveryErr) {
+		exit(exitDeliveryFailure, "error sending report %s: %s", stored.ID, err)
+	}
+	if err != nil {
+		exit(exitFailure, "error sending report %s: %s", stored.ID, err)
+	}
+}
+
+// loadReport loads the stored report with the given ID, or the latest report when the ID is empty.
+func loadReport(reportDir, id string) report.Report {
+	store := report.NewStore(reportDir)
+	var stored report.Report
+	var err error
+	if id != "" {
+		stored, err = store.Load(id)
+	} else {
+		stored, err = store.Latest()
+	}
+	if err != nil {
+		exit(exitFailure, "error loading report: %s", err)
+	}
+	return stored
+}
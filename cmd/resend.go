@@ -0,0 +1,72 @@
+package main
+
+import (
+	"data-insights/kit/email"
+	"data-insights/pkg"
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// Resend sends the messages queued in the outbox without recomputing anything: the pending messages left behind
+// by an interrupted run, or with -dead the messages in the dead-letter directory.
+func Resend(args []string) {
+	flags := flag.NewFlagSet("resend", flag.ExitOnError)
+	configFile := addSettingFlags(flags, "outbox")
+	dead := flags.Bool("dead", false, "replay the messages in the dead-letter directory instead of the pending ones")
+	ids := flags.String("id", "", "comma-separated IDs of the dead-letter messages to replay (defaults to all)")
+	list := flags.Bool("list", false, "list the queued messages without sending them")
+	_ = flags.Parse(args)
+
+	if err := applySettings(flags, *configFile); err != nil {
+		exit(exitUsage, "%s", err)
+	}
+	envVariables, err := getEnvVariables(envRequirements{})
+	if err != nil {
+		exit(exitUsage, "error loading environment variables: %s", err)
+	}
+
+	if *list {
+		listOutbox(email.NewOutbox(envVariables.OutboxDirectory, nil, 0, 0))
+		return
+	}
+
+	outbox, err := pkg.NewOutbox(envVariables)
+	if err != nil {
+		exit(exitUsage, "error creating outbox: %s", err)
+	}
+
+	var sent int
+	if *dead {
+		var selected []string
+		if *ids != "" {
+			selected = strings.Split(*ids, ",")
+		}
+		sent, err = outbox.Replay(selected)
+	} else {
+		sent, err = outbox.Flush()
+	}
+	log.Printf("%d queued messages sent", sent)
+	if err != nil {
+		exit(exitDeliveryFailure, "error resending messages: %s", err)
+	}
+}
+
+// listOutbox prints the pending and dead-letter messages with their recipients, subjects and last errors.
+func listOutbox(outbox *email.Outbox) {
+	for _, subdir := range []string{email.OutboxPendingDir, email.OutboxDeadLetterDir} {
+		messages, err := outbox.List(subdir)
+		if err != nil {
+			exit(exitFailure, "error listing outbox: %s", err)
+		}
+		fmt.Printf("%s (%d):\n", subdir, len(messages))
+		for _, queued := range messages {
+			fmt.Printf("  %s  %s  %q  attempts: %d", queued.ID, strings.Join(queued.Message.To, ", "), queued.Message.Subject, queued.Attempts)
+			if queued.LastError != "" {
+				fmt.Printf("  last error: %s", queued.LastError)
+			}
+			fmt.Println()
+		}
+	}
+}
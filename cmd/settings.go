@@ -0,0 +1,154 @@
+package main
+
+import (
+	"data-insights/kit/config"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/joho/godotenv"
+	"os"
+	"strings"
+)
+
+const defaultConfigFile = ".env"
+
+// setting is a configuration value that can be set with a command-line flag as well as an environment variable.
+type setting struct {
+	flag   string
+	env    string
+	usage  string
+	isBool bool
+}
+
+var settings = []setting{
+	{flag: "files", env: "FILE_DIR", usage: "directory of data files, an s3://bucket/prefix or an http(s) URL"},
+	{flag: "include", env: "FILE_INCLUDE", usage: "comma-separated glob patterns of the data files"},
+	{flag: "exclude", env: "FILE_EXCLUDE", usage: "comma-separated glob patterns of the files and directories to leave out"},
+	{flag: "extensions", env: "FILE_EXTENSIONS", usage: "comma-separated extensions of the data files, * for every file (default .json)"},
+	{flag: "max-depth", env: "FILE_MAX_DEPTH", usage: "levels of directories to walk, 1 for the files in the data directory only"},
+	{flag: "symlinks", env: "FILE_SYMLINKS", usage: "symbolic links: skip, files or follow (default files)"},
+	{flag: "hidden", env: "FILE_HIDDEN", usage: "include hidden files and directories", isBool: true},
+	{flag: "modified-since", env: "FILE_MODIFIED_SINCE", usage: "leave out files modified before a duration ago such as 24h, a date or an RFC 3339 time"},
+	{flag: "reports", env: "REPORT_DIR", usage: "directory of stored reports"},
+	{flag: "engine", env: "INSIGHT_ENGINE", usage: "insight engine: auto, llm or rules"},
+	{flag: "channels", env: "CHANNELS_FILE", usage: "channels file"},
+	{flag: "recipients", env: "RECIPIENTS_FILE", usage: "recipients file"},
+	{flag: "to", env: "EMAIL_TO", usage: "recipient email address"},
+	{flag: "templates", env: "TEMPLATE_DIR", usage: "template directory"},
+	{flag: "theme", env: "THEME_FILE", usage: "theme file"},
+	{flag: "formats", env: "OUTPUT_FORMATS", usage: "comma-separated report file formats: html, markdown, json"},
+	{flag: "output", env: "OUTPUT_PATH", usage: "report file path template"},
+	{flag: "charts", env: "CHART_FORMAT", usage: "chart format: png, svg or none"},
+	{flag: "pdf", env: "PDF_ATTACHMENT", usage: "attach the report to emails as a PDF", isBool: true},
+	{flag: "pdf-dir", env: "PDF_DIR", usage: "directory to write PDF reports to"},
+	{flag: "digest", env: "DIGEST", usage: "send one digest for all files", isBool: true},
+	{flag: "outbox", env: "OUTBOX_DIR", usage: "outbox directory"},
+	{flag: "ledger", env: "LEDGER_FILE", usage: "ledger of the processed files"},
+	{flag: "concurrency", env: "CONCURRENCY", usage: "number of files processed at a time"},
+	{flag: "continue-on-error", env: "CONTINUE_ON_ERROR", usage: "process every file even if one fails, and print a summary", isBool: true},
+	{flag: "operator", env: "OPERATOR_EMAIL", usage: "email address to send the failed files to"},
+}
+
+// fileFilterSettings are the settings that select the data files in FILE_DIR.
+var fileFilterSettings = []string{"include", "exclude", "extensions", "max-depth", "symlinks", "hidden", "modified-since"}
+
+// addSettingFlags registers the -config flag and the flags of the named settings on the flag set, and returns
+// the config file path. The files setting comes with the settings that filter the data files. The config file
+// defaults to CONFIG_FILE, or .env.
+func addSettingFlags(flags *flag.FlagSet, names ...string) *string {
+	configFile := flags.String("config", getEnvOrDefault("CONFIG_FILE", defaultConfigFile),
+		"config file: a YAML or TOML config, or KEY=value settings")
+	var expanded []string
+	for _, name := range names {
+		expanded = append(expanded, name)
+		if name == "files" {
+			expanded = append(expanded, fileFilterSettings...)
+		}
+	}
+	for _, name := range expanded {
+		s, ok := findSetting(name)
+		if !ok {
+			panic(fmt.Sprintf("unknown setting flag: %s", name))
+		}
+		usage := fmt.Sprintf("%s (overrides %s)", s.usage, s.env)
+		if s.isBool {
+			flags.Bool(s.flag, false, usage)
+		} else {
+			flags.String(s.flag, "", usage)
+		}
+	}
+	return configFile
+}
+
+// applySettings layers the configuration: flags set on the command line override environment variables, which
+// override the config file. The default config file is optional; one given with -config or CONFIG_FILE must exist.
+// A YAML or TOML config file is validated first, and every problem in it is reported at once.
+func applySettings(flags *flag.FlagSet, configFile string) error {
+	explicit := os.Getenv("CONFIG_FILE") != ""
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == "config" {
+			explicit = true
+		}
+	})
+	if err := loadSettingsFile(configFile, explicit); err != nil {
+		return err
+	}
+
+	var err error
+	flags.Visit(func(f *flag.Flag) {
+		if s, ok := findSetting(f.Name); ok && err == nil {
+			err = os.Setenv(s.env, f.Value.String())
+		}
+	})
+	return err
+}
+
+// loadSettingsFile sets the environment variables of the settings in the config file that are not already set.
+// A missing file is only an error if it was given explicitly.
+func loadSettingsFile(configFile string, explicit bool) error {
+	if config.IsConfigFile(configFile) {
+		return loadConfigFile(configFile)
+	}
+	// godotenv never overrides variables that are already set
+	if err := godotenv.Load(configFile); err != nil {
+		if explicit || !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("error loading config file %s: %v", configFile, err)
+		}
+	}
+	return nil
+}
+
+// loadConfigFile loads and validates a YAML or TOML config file and sets the environment variables of its
+// settings, except those that are already set. CONFIG_FILE is set to the file, which also holds the recipients and
+// channels.
+func loadConfigFile(path string) error {
+	configuration, err := config.Load(path)
+	if err != nil {
+		return err
+	}
+	if err := configuration.Validate(); err != nil {
+		return fmt.Errorf("invalid config file %s:\n  - %s", path, strings.ReplaceAll(err.Error(), "\n", "\n  - "))
+	}
+	env, err := configuration.Env()
+	if err != nil {
+		return fmt.Errorf("invalid config file %s: %v", path, err)
+	}
+	for key, value := range env {
+		if _, ok := os.LookupEnv(key); ok {
+			continue
+		}
+		if err := os.Setenv(key, value); err != nil {
+			return err
+		}
+	}
+	return os.Setenv("CONFIG_FILE", path)
+}
+
+func findSetting(name string) (setting, bool) {
+	for _, s := range settings {
+		if s.flag == name {
+			return s, true
+		}
+	}
+	return setting{}, false
+}
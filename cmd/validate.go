@@ -0,0 +1,46 @@
+package main
+
+import (
+	"data-insights/pkg"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// Validate checks the configuration a run depends on, without processing any files or sending anything, and
+// prints every problem found. It exits with the usage exit code if the configuration is invalid.
+func Validate(args []string) {
+	flags := flag.NewFlagSet("validate", flag.ExitOnError)
+	configFile := addSettingFlags(flags, "files", "reports", "engine", "channels", "recipients", "to", "templates",
+		"theme", "formats", "output", "charts", "pdf", "pdf-dir", "digest", "outbox")
+	dryRun := flags.Bool("dry-run", false, "validate for a dry run, which needs no SMTP server")
+	_ = flags.Parse(args)
+
+	if err := applySettings(flags, *configFile); err != nil {
+		exit(exitUsage, "%s", err)
+	}
+
+	var errs []error
+	envVariables, err := getEnvVariables(envRequirements{Files: true, Delivery: true, DryRun: *dryRun})
+	if err != nil {
+		errs = append(errs, err)
+	} else {
+		envVariables.DryRun = *dryRun
+		if _, err := newInsightsClient(envVariables); err != nil {
+			errs = append(errs, err)
+		}
+		if err := pkg.ValidateConfig(envVariables); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		fmt.Println("Configuration is invalid:")
+		for _, problem := range strings.Split(err.Error(), "\n") {
+			fmt.Printf("  - %s\n", problem)
+		}
+		exit(exitUsage, "validation failed")
+	}
+	fmt.Println("Configuration is valid")
+}
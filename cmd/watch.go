@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"data-insights/kit/email"
+	"data-insights/kit/file"
+	"data-insights/kit/watch"
+	"data-insights/pkg"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// Watch processes the data files in FILE_DIR as they arrive, until it receives SIGINT or SIGTERM. A file is
+// processed once it is completely written, and recorded in the ledger by content hash, so that it is never
+// reported twice, also across restarts. A file that changes is processed again.
+func Watch(args []string) {
+	flags := flag.NewFlagSet("watch", flag.ExitOnError)
+	configFile := addSettingFlags(flags, "files", "reports", "engine", "channels", "recipients", "to", "templates",
+		"theme", "formats", "output", "charts", "pdf", "pdf-dir", "digest", "outbox", "ledger", "concurrency")
+	interval := flags.Duration("interval", watch.DefaultInterval, "how often to look for new files")
+	stableFor := flags.Duration("stable", watch.DefaultStableFor, "how long a file must be unchanged to be complete")
+	marker := flags.String("marker", watch.DefaultMarkerSuffix, "suffix of the marker file that flags a file as complete, empty to disable")
+	dryRun := flags.Bool("dry-run", false, "write emails and webhook payloads to disk instead of sending them")
+	dryRunDir := flags.String("dry-run-dir", email.DryRunDir, "directory for dry run output")
+	_ = flags.Parse(args)
+
+	if err := applySettings(flags, *configFile); err != nil {
+		exit(exitUsage, "%s", err)
+	}
+	envVariables, err := getEnvVariables(envRequirements{Files: true, Delivery: true, DryRun: *dryRun})
+	if err != nil {
+		exit(exitUsage, "error loading environment variables: %s", err)
+	}
+	if file.IsRemote(envVariables.FileDirectory) {
+		exit(exitUsage, "FILE_DIR must be a local directory to watch, %s is remote", envVariables.FileDirectory)
+	}
+	envVariables.DryRun = *dryRun
+	envVariables.DryRunDirectory = *dryRunDir
+
+	insightsClient, err := newInsightsClient(envVariables)
+	if err != nil {
+		exit(exitUsage, "error creating insights client: %s", err)
+	}
+	pipeline, err := pkg.NewPipeline(envVariables, insightsClient)
+	if err != nil {
+		exit(exitUsage, "%s", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	watcher := watch.NewWatcher(envVariables.FileDirectory, pkg.WalkOptions(envVariables), *interval, *stableFor, *marker)
+	log.Printf("Watching %s for new files", envVariables.FileDirectory)
+	watcher.Watch(ctx, func(files []string) {
+		processNewFiles(pipeline, files, envVariables.Digest, envVariables.Concurrency)
+	})
+	log.Printf("Stopped watching %s", envVariables.FileDirectory)
+}
+
+// processNewFiles processes the complete files, up to concurrency at a time, or together as a digest. The
+// pipeline skips the files that are in the ledger with their current content and records the others. Failures are
+// logged, and the file is retried when it changes or after a restart; a failure does not hold back other files.
+func processNewFiles(pipeline *pkg.Pipeline, files []string, digest bool, concurrency int) {
+	if digest {
+		if _, err := pipeline.Process(files); err != nil {
+			log.Printf("Error processing files: %s", err)
+		}
+		return
+	}
+
+	slots := make(chan struct{}, max(concurrency, 1))
+	var wg sync.WaitGroup
+	for _, fileSource := range files {
+		slots <- struct{}{}
+		wg.Add(1)
+		go func(fileSource string) {
+			defer func() { <-slots; wg.Done() }()
+			if _, err := pipeline.Process([]string{fileSource}); err != nil {
+				log.Printf("Error processing files: %s", err)
+			}
+		}(fileSource)
+	}
+	wg.Wait()
+}
@@ -2,4 +2,10 @@ module data-insights
 
 go 1.21.5
 
-require github.com/joho/godotenv v1.5.1
+require (
+	github.com/BurntSushi/toml v1.4.0
+	github.com/go-pdf/fpdf v0.9.0
+	github.com/joho/godotenv v1.5.1
+	golang.org/x/image v0.20.0
+	gopkg.in/yaml.v3 v3.0.1
+)
@@ -10,10 +10,53 @@ import (
 )
 
 type Client interface {
-	makeRequestAndGetResponse(apiKey, prompt string) (OpenAIResponse, error)
 	GetInsightsFromLLM(apiKey string, data common.UserMetrics) (string, error)
 }
 
+// ToolClient is implemented by clients that can investigate the raw data through tools before writing insights.
+type ToolClient interface {
+	GetInsightsWithTools(apiKey string, data common.UserMetrics, toolbox *Toolbox) (string, []common.ToolCallLog, error)
+}
+
+// DigestClient is implemented by clients that can summarise how several properties compare for a digest.
+type DigestClient interface {
+	GetDigestSummary(apiKey string, properties []common.PropertyMetrics) (string, error)
+}
+
+// ModelClient is implemented by clients that can name the model that writes their insights.
+type ModelClient interface {
+	Model() string
+}
+
+// Generation is the insights written for one request, with the tool calls that were made and the model that wrote
+// them.
+type Generation struct {
+	Insights  string
+	ToolCalls []common.ToolCallLog
+	Model     string
+}
+
+// Generate asks the client for insights. Clients that support tools can drill into the data with the toolbox, if
+// one is given. The generation names the model that wrote the insights, which for the fallback client depends on
+// whether it fell back, and is empty for clients that cannot name their model.
+func Generate(client Client, apiKey string, data common.UserMetrics, toolbox *Toolbox) (Generation, error) {
+	if fallback, ok := client.(FallbackClient); ok {
+		return fallback.generate(apiKey, data, toolbox)
+	}
+
+	var generation Generation
+	if modelClient, ok := client.(ModelClient); ok {
+		generation.Model = modelClient.Model()
+	}
+	var err error
+	if toolClient, ok := client.(ToolClient); ok && toolbox != nil {
+		generation.Insights, generation.ToolCalls, err = toolClient.GetInsightsWithTools(apiKey, data, toolbox)
+	} else {
+		generation.Insights, err = client.GetInsightsFromLLM(apiKey, data)
+	}
+	return generation, err
+}
+
 type OpenAIClient struct {
 	AIModel    AIModel
 	Url        string
@@ -32,20 +75,34 @@ func NewOpenAIClient(model AIModel, url string, maxTokens int, senderRole string
 	}
 }
 
+// Model returns the OpenAI model that the client requests.
+func (o OpenAIClient) Model() string {
+	return string(o.AIModel)
+}
+
 // makeRequestAndGetResponse creates an HTTP POST request to the OpenAI API with the provided API key and prompt,
 // and returns the API response. It returns an OpenAIResponse object if successful, or an error if the request
 // fails or the response cannot be parsed.
-
 func (o OpenAIClient) makeRequestAndGetResponse(apiKey, prompt string) (OpenAIResponse, error) {
-	requestBody, err := json.Marshal(map[string]interface{}{
-		"model": o.AIModel,
-		"messages": []RequestMessage{{
-			Role:    o.SenderRole,
-			Content: prompt,
-		},
-		},
+	return o.sendChatRequest(apiKey, []RequestMessage{{
+		Role:    o.SenderRole,
+		Content: prompt,
+	}}, nil)
+}
+
+// sendChatRequest posts the conversation and, if any, the available tools to the OpenAI API and returns the
+// parsed response. It returns an error if the request fails, the API responds with an error status or the
+// response cannot be parsed.
+func (o OpenAIClient) sendChatRequest(apiKey string, messages []RequestMessage, tools []Tool) (OpenAIResponse, error) {
+	payload := map[string]interface{}{
+		"model":      o.AIModel,
+		"messages":   messages,
 		"max_tokens": o.MaxTokens,
-	})
+	}
+	if len(tools) > 0 {
+		payload["tools"] = tools
+	}
+	requestBody, err := json.Marshal(payload)
 	if err != nil {
 		return OpenAIResponse{}, fmt.Errorf("failed to marshal request body: %v", err)
 	}
@@ -56,7 +113,10 @@ func (o OpenAIClient) makeRequestAndGetResponse(apiKey, prompt string) (OpenAIRe
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+apiKey)
 
-	client := &http.Client{}
+	client := o.HttpClient
+	if client == nil {
+		client = &http.Client{}
+	}
 	resp, err := client.Do(req)
 	if err != nil {
 		return OpenAIResponse{}, fmt.Errorf("failed to make request: %v", err)
@@ -67,6 +127,9 @@ func (o OpenAIClient) makeRequestAndGetResponse(apiKey, prompt string) (OpenAIRe
 	if err != nil {
 		return OpenAIResponse{}, fmt.Errorf("failed to read response body: %v", err)
 	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return OpenAIResponse{}, fmt.Errorf("request failed with status %d: %s", resp.StatusCode, body)
+	}
 
 	var aiResponse OpenAIResponse
 	if err := json.Unmarshal(body, &aiResponse); err != nil {
@@ -75,6 +138,19 @@ func (o OpenAIClient) makeRequestAndGetResponse(apiKey, prompt string) (OpenAIRe
 	return aiResponse, nil
 }
 
+// Chat sends the conversation together with the available tools and returns the model's reply, which either
+// carries content or a list of tool calls to execute. It returns an error if the API returns no choices.
+func (o OpenAIClient) Chat(apiKey string, messages []RequestMessage, tools []Tool) (RequestMessage, error) {
+	aiResponse, err := o.sendChatRequest(apiKey, messages, tools)
+	if err != nil {
+		return RequestMessage{}, fmt.Errorf("failed to make request and get response: %v", err)
+	}
+	if len(aiResponse.Choices) == 0 {
+		return RequestMessage{}, fmt.Errorf("no choices in the response")
+	}
+	return aiResponse.Choices[0].Message, nil
+}
+
 // GetInsightsFromLLM generates insights from the given UserMetrics data
 // by sending a request to the OpenAI API and returning the first response choice
 // as a string. It returns an error if the request fails or if the API returns no choices.
@@ -91,3 +167,24 @@ func (o OpenAIClient) GetInsightsFromLLM(apiKey string, data common.UserMetrics)
 
 	return aiResponse.Choices[0].Message.Content, nil
 }
+
+// GetInsightsWithTools generates insights like GetInsightsFromLLM, but lets the model call the tools in the toolbox
+// to drill into anything surprising before it writes the insights. The number of tool rounds is bounded by
+// MaxToolRounds. It returns the insights together with a log of every executed tool call.
+func (o OpenAIClient) GetInsightsWithTools(apiKey string, data common.UserMetrics, toolbox *Toolbox) (string, []common.ToolCallLog, error) {
+	var toolCalls []common.ToolCallLog
+	conversation := NewConversation(o, apiKey, InsightsToolPrompt, toolbox, MaxToolRounds)
+	conversation.OnToolCall = func(call ToolCall, result string) {
+		toolCalls = append(toolCalls, common.ToolCallLog{
+			Name:      call.Function.Name,
+			Arguments: call.Function.Arguments,
+			Result:    result,
+		})
+	}
+
+	insights, err := conversation.Ask(createPrompt(data))
+	if err != nil {
+		return "", toolCalls, err
+	}
+	return insights, toolCalls, nil
+}
@@ -9,3 +9,27 @@ const (
 	OpenAIMaxTokens  = 1500
 	OpenAISenderRole = "user"
 )
+
+type Engine string
+
+const (
+	EngineAuto  Engine = "auto"  // LLM with rule-based fallback
+	EngineLLM   Engine = "llm"   // LLM only
+	EngineRules Engine = "rules" // rule-based only, for air-gapped deployments
+)
+
+const (
+	OpenAISystemRole    = "system"
+	OpenAIAssistantRole = "assistant"
+	OpenAIToolRole      = "tool"
+	OpenAIToolType      = "function"
+	MaxToolRounds       = 5
+)
+
+const (
+	LevelHigh   = "high"
+	LevelMedium = "medium"
+	LevelLow    = "low"
+)
+
+const EvidenceTolerance = 0.01
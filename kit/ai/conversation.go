@@ -0,0 +1,59 @@
+package ai
+
+import "fmt"
+
+// Conversation is a multi-turn chat with the model in which the model may call tools before answering.
+type Conversation struct {
+	client        OpenAIClient
+	apiKey        string
+	toolbox       *Toolbox
+	maxToolRounds int
+	messages      []RequestMessage
+	// OnToolCall, if set, is called after every executed tool call.
+	OnToolCall func(call ToolCall, result string)
+}
+
+func NewConversation(client OpenAIClient, apiKey, systemPrompt string, toolbox *Toolbox, maxToolRounds int) *Conversation {
+	return &Conversation{
+		client:        client,
+		apiKey:        apiKey,
+		toolbox:       toolbox,
+		maxToolRounds: maxToolRounds,
+		messages:      []RequestMessage{{Role: OpenAISystemRole, Content: systemPrompt}},
+	}
+}
+
+// Ask adds the question to the conversation and returns the model's answer. Tool calls requested by the model
+// are executed and their results sent back until the model answers or the tool round limit is reached.
+func (c *Conversation) Ask(question string) (string, error) {
+	c.messages = append(c.messages, RequestMessage{Role: OpenAISenderRole, Content: question})
+
+	for round := 0; ; round++ {
+		tools := c.toolbox.Definitions()
+		if round >= c.maxToolRounds {
+			// Withhold the tools so that the model has to answer with what it has gathered so far.
+			tools = nil
+		}
+
+		reply, err := c.client.Chat(c.apiKey, c.messages, tools)
+		if err != nil {
+			return "", err
+		}
+		c.messages = append(c.messages, reply)
+
+		if len(reply.ToolCalls) == 0 {
+			return reply.Content, nil
+		}
+		if tools == nil {
+			return "", fmt.Errorf("model requested tools after %d tool rounds", c.maxToolRounds)
+		}
+
+		for _, call := range reply.ToolCalls {
+			result := c.toolbox.Call(call)
+			if c.OnToolCall != nil {
+				c.OnToolCall(call, result)
+			}
+			c.messages = append(c.messages, RequestMessage{Role: OpenAIToolRole, Content: result, ToolCallID: call.ID})
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package ai
+
+import (
+	"data-insights/kit/common"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+)
+
+// GetDigestSummary asks the model for a short cross-property summary comparing the overall metrics of every
+// property in a digest. It returns an error if the request fails or the API returns no choices.
+func (o OpenAIClient) GetDigestSummary(apiKey string, properties []common.PropertyMetrics) (string, error) {
+	aiResponse, err := o.makeRequestAndGetResponse(apiKey, createDigestPrompt(properties))
+	if err != nil {
+		return "", fmt.Errorf("failed to make request and get response: %v", err)
+	}
+	if len(aiResponse.Choices) == 0 {
+		return "", fmt.Errorf("no choices in the response")
+	}
+	return strings.TrimSpace(aiResponse.Choices[0].Message.Content), nil
+}
+
+// GetDigestSummary names the properties with the highest and lowest engagement rate and the one with the highest
+// bounce rate. The API key is ignored.
+func (r RuleBasedClient) GetDigestSummary(_ string, properties []common.PropertyMetrics) (string, error) {
+	if len(properties) == 0 {
+		return "", nil
+	}
+	if len(properties) == 1 {
+		property := properties[0]
+		return fmt.Sprintf("%s has an engagement rate of %s and a bounce rate of %s.",
+			property.Name, formatPercent(property.EngagementRate()), formatPercent(property.BounceRate)), nil
+	}
+
+	highest, lowest, bounciest := properties[0], properties[0], properties[0]
+	for _, property := range properties[1:] {
+		if property.OverallEngagementRate > highest.OverallEngagementRate {
+			highest = property
+		}
+		if property.OverallEngagementRate < lowest.OverallEngagementRate {
+			lowest = property
+		}
+		if property.BounceRate > bounciest.BounceRate {
+			bounciest = property
+		}
+	}
+
+	gap := highest.EngagementRate() - lowest.EngagementRate()
+	summary := fmt.Sprintf("Across %d properties, %s has the highest engagement rate at %s and %s the lowest at %s, "+
+		"a gap of %.2f points.", len(properties), highest.Name, formatPercent(highest.EngagementRate()), lowest.Name,
+		formatPercent(lowest.EngagementRate()), gap)
+	if math.Abs(gap) < 0.005 {
+		summary = fmt.Sprintf("All %d properties have an engagement rate of %s.", len(properties),
+			formatPercent(highest.EngagementRate()))
+	}
+	if bounciest.BounceRate > 0 {
+		summary += fmt.Sprintf(" %s has the highest bounce rate at %s.", bounciest.Name, formatPercent(bounciest.BounceRate))
+	}
+	return summary, nil
+}
+
+// GetDigestSummary returns the primary client's summary, or the fallback's when no API key is set, the primary
+// client cannot summarise digests or its request fails.
+func (f FallbackClient) GetDigestSummary(apiKey string, properties []common.PropertyMetrics) (string, error) {
+	primary, ok := f.Primary.(DigestClient)
+	if ok && strings.TrimSpace(apiKey) != "" {
+		summary, err := primary.GetDigestSummary(apiKey, properties)
+		if err == nil && summary != "" {
+			return summary, nil
+		}
+		log.Printf("falling back to secondary digest summary: %v", err)
+	}
+
+	fallback, ok := f.Fallback.(DigestClient)
+	if !ok {
+		return "", fmt.Errorf("no client can summarise the digest")
+	}
+	return fallback.GetDigestSummary(apiKey, properties)
+}
+
+// createDigestPrompt lists the overall metrics of every property and asks for a cross-property summary.
+func createDigestPrompt(properties []common.PropertyMetrics) string {
+	var list strings.Builder
+	for _, property := range properties {
+		fmt.Fprintf(&list, "%s:\n", property.Name)
+		fmt.Fprintf(&list, "  - Overall Engagement Rate: %.2f%%\n", property.EngagementRate())
+		fmt.Fprintf(&list, "  - Average Session Duration: %.2f seconds\n", property.AverageSessionDuration)
+		fmt.Fprintf(&list, "  - Bounce Rate: %.2f%%\n", property.BounceRate)
+		fmt.Fprintf(&list, "  - Pages Per Session: %.2f\n", property.PagesPerSession)
+		fmt.Fprintf(&list, "  - New User Percentage: %.2f%%\n", property.NewUserPercentage)
+		fmt.Fprintf(&list, "  - Session Per User: %.2f\n", property.SessionPerUser)
+	}
+	return fmt.Sprintf(DigestPromptFormat, list.String())
+}
+
+const DigestPromptFormat = `
+You are a web analytics expert writing the opening summary of a digest that covers several websites, called
+properties. Each property has its own detailed report in the digest, so focus on how they compare.
+
+Overall metrics per property:
+%s
+Write a cross-property summary of 3 to 5 sentences: name the strongest and weakest properties, point out the
+largest differences between them and suggest where attention is most needed. Only use the numbers listed above.
+Reply in plain text, without headings, lists or markdown.
+`
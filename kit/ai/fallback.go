@@ -0,0 +1,58 @@
+package ai
+
+import (
+	"data-insights/kit/common"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// FallbackClient asks the primary client first and falls back to a secondary client when the primary fails
+// or returns output that is not valid insights JSON.
+type FallbackClient struct {
+	Primary  Client
+	Fallback Client
+}
+
+func NewFallbackClient(primary, fallback Client) FallbackClient {
+	return FallbackClient{Primary: primary, Fallback: fallback}
+}
+
+// GetInsightsFromLLM returns the primary client's insights, or the fallback's when no API key is set,
+// the primary request fails or its output cannot be parsed.
+func (f FallbackClient) GetInsightsFromLLM(apiKey string, data common.UserMetrics) (string, error) {
+	generation, err := f.generate(apiKey, data, nil)
+	return generation.Insights, err
+}
+
+// GetInsightsWithTools behaves like GetInsightsFromLLM, but lets the primary client use the toolbox if it supports
+// tools. Insights produced by the fallback client carry no tool calls.
+func (f FallbackClient) GetInsightsWithTools(apiKey string, data common.UserMetrics, toolbox *Toolbox) (string, []common.ToolCallLog, error) {
+	generation, err := f.generate(apiKey, data, toolbox)
+	return generation.Insights, generation.ToolCalls, err
+}
+
+// generate asks the primary client, with the toolbox if one is given, and the fallback client without it when no
+// API key is set, the primary request fails or its output cannot be parsed. The generation names the model of the
+// client whose insights are returned.
+func (f FallbackClient) generate(apiKey string, data common.UserMetrics, toolbox *Toolbox) (Generation, error) {
+	if strings.TrimSpace(apiKey) == "" {
+		return Generate(f.Fallback, apiKey, data, nil)
+	}
+
+	generation, err := Generate(f.Primary, apiKey, data, toolbox)
+	if err == nil {
+		var parsed common.UserMetricsWithInsights
+		if err = json.Unmarshal([]byte(generation.Insights), &parsed); err == nil {
+			return generation, nil
+		}
+	}
+
+	log.Printf("falling back to secondary insights client: %v", err)
+	fallback, fallbackErr := Generate(f.Fallback, apiKey, data, nil)
+	if fallbackErr != nil {
+		return Generation{}, fmt.Errorf("primary client failed: %v, fallback client failed: %v", err, fallbackErr)
+	}
+	return fallback, nil
+}
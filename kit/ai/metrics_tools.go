@@ -0,0 +1,268 @@
+package ai
+
+import (
+	"data-insights/kit/common"
+	"data-insights/kit/metrics"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	defaultToolResultLimit   = 10
+	defaultToolMinDataPoints = 1
+)
+
+var breakdownNames = []string{string(common.COUNTRY), string(common.DEVICE), string(common.PAGE), string(common.MEDIUM)}
+
+var metricNames = []string{
+	string(common.NAME), string(common.AVGENGAGEMENTRATE), string(common.TOTALSESSIONS), string(common.TOTALPAGEVIEWS),
+	string(common.AVGSESSIONDURATION), string(common.BOUNCERATE), string(common.TOTALNEWUSERS), string(common.TOTALUSERS),
+	string(common.AVGENGAGEMENTDURATION), string(common.DATAPOINTCOUNT),
+}
+
+type DimensionFilter struct {
+	Dimension common.Breakdown `json:"dimension"`
+	Value     string           `json:"value"`
+}
+
+// dataFilter narrows the raw data by dimension values and an inclusive date range before a tool runs.
+type dataFilter struct {
+	Filters []DimensionFilter `json:"filters"`
+	From    string            `json:"from"`
+	To      string            `json:"to"`
+}
+
+type aggregateMetricsArguments struct {
+	dataFilter
+	Breakdown     common.Breakdown `json:"breakdown"`
+	SortBy        common.Metric    `json:"sort_by"`
+	Order         common.SortOrder `json:"order"`
+	Limit         int              `json:"limit"`
+	MinDataPoints int              `json:"min_data_points"`
+}
+
+type period struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type comparePeriodsArguments struct {
+	Filters []DimensionFilter `json:"filters"`
+	PeriodA period            `json:"period_a"`
+	PeriodB period            `json:"period_b"`
+}
+
+// NewMetricsToolbox exposes the metrics engine over the given raw data as tools the model can call.
+func NewMetricsToolbox(data []common.Insight) *Toolbox {
+	toolbox := NewToolbox()
+	toolbox.Register(ToolFunction{
+		Name:        "aggregate_metrics",
+		Description: "Aggregates the raw analytics data by a breakdown dimension, optionally filtered by dimension values and dates, and returns engagement rate (0-1), sessions, page views, session duration in seconds, bounce rate (%), users and data point count per group.",
+		Parameters: objectSchema(withFilterProperties(map[string]interface{}{
+			"breakdown":       map[string]interface{}{"type": "string", "enum": breakdownNames, "description": "Dimension to group by."},
+			"sort_by":         map[string]interface{}{"type": "string", "enum": metricNames},
+			"order":           map[string]interface{}{"type": "string", "enum": []string{string(common.DESC), string(common.ASC)}},
+			"limit":           map[string]interface{}{"type": "integer", "description": "Maximum number of groups to return, 10 by default."},
+			"min_data_points": map[string]interface{}{"type": "integer", "description": "Minimum number of data points per group, 1 by default."},
+		}), "breakdown"),
+	}, func(arguments string) (string, error) {
+		return aggregateMetrics(data, arguments)
+	})
+	toolbox.Register(ToolFunction{
+		Name:        "filter_metrics",
+		Description: "Calculates the overall metrics (engagement rate 0-1, session duration in seconds, bounce rate %, pages per session, new user %, sessions per user) for the data points matching dimension values and dates.",
+		Parameters:  objectSchema(withFilterProperties(map[string]interface{}{})),
+	}, func(arguments string) (string, error) {
+		return filterMetrics(data, arguments)
+	})
+	toolbox.Register(ToolFunction{
+		Name:        "compare_periods",
+		Description: "Calculates the overall metrics for two date ranges, optionally filtered by dimension values, and the difference between them (period B minus period A).",
+		Parameters: objectSchema(map[string]interface{}{
+			"filters":  filtersSchema(),
+			"period_a": periodSchema(),
+			"period_b": periodSchema(),
+		}, "period_a", "period_b"),
+	}, func(arguments string) (string, error) {
+		return comparePeriods(data, arguments)
+	})
+	toolbox.Register(ToolFunction{
+		Name:        "daily_series",
+		Description: "Returns the sessions and overall metrics for every day, optionally filtered by dimension values and dates.",
+		Parameters:  objectSchema(withFilterProperties(map[string]interface{}{})),
+	}, func(arguments string) (string, error) {
+		return dailySeries(data, arguments)
+	})
+	return toolbox
+}
+
+func aggregateMetrics(data []common.Insight, arguments string) (string, error) {
+	var args aggregateMetricsArguments
+	if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+		return "", fmt.Errorf("invalid arguments: %v", err)
+	}
+	if !metrics.IsValidBreakdown(args.Breakdown) {
+		return "", fmt.Errorf("unknown breakdown: %s", args.Breakdown)
+	}
+
+	filtered, err := args.apply(data)
+	if err != nil {
+		return "", err
+	}
+
+	if args.MinDataPoints <= 0 {
+		args.MinDataPoints = defaultToolMinDataPoints
+	}
+	aggregated := metrics.AggregateMetricsByBreakdown(filtered, args.Breakdown, args.MinDataPoints)
+
+	if args.SortBy == "" {
+		args.SortBy = common.TOTALSESSIONS
+	}
+	if args.Order == "" {
+		args.Order = common.DESC
+	}
+	aggregated.SortByField(args.SortBy, args.Order)
+
+	if args.Limit <= 0 {
+		args.Limit = defaultToolResultLimit
+	}
+	if len(aggregated) > args.Limit {
+		aggregated = aggregated[:args.Limit]
+	}
+
+	return marshalToolResult(map[string]interface{}{
+		"data_points": len(filtered),
+		"groups":      aggregated,
+	})
+}
+
+func filterMetrics(data []common.Insight, arguments string) (string, error) {
+	var args dataFilter
+	if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+		return "", fmt.Errorf("invalid arguments: %v", err)
+	}
+	filtered, err := args.apply(data)
+	if err != nil {
+		return "", err
+	}
+	return marshalToolResult(map[string]interface{}{
+		"data_points": len(filtered),
+		"metrics":     metrics.CalculateOverallMetrics(filtered),
+	})
+}
+
+func comparePeriods(data []common.Insight, arguments string) (string, error) {
+	var args comparePeriodsArguments
+	if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+		return "", fmt.Errorf("invalid arguments: %v", err)
+	}
+
+	periodA, err := dataFilter{Filters: args.Filters, From: args.PeriodA.From, To: args.PeriodA.To}.apply(data)
+	if err != nil {
+		return "", err
+	}
+	periodB, err := dataFilter{Filters: args.Filters, From: args.PeriodB.From, To: args.PeriodB.To}.apply(data)
+	if err != nil {
+		return "", err
+	}
+
+	a, b := metrics.CalculateOverallMetrics(periodA), metrics.CalculateOverallMetrics(periodB)
+	return marshalToolResult(map[string]interface{}{
+		"period_a": map[string]interface{}{"data_points": len(periodA), "metrics": a},
+		"period_b": map[string]interface{}{"data_points": len(periodB), "metrics": b},
+		"difference": common.OverallMetrics{
+			OverallEngagementRate:  b.OverallEngagementRate - a.OverallEngagementRate,
+			AverageSessionDuration: b.AverageSessionDuration - a.AverageSessionDuration,
+			BounceRate:             b.BounceRate - a.BounceRate,
+			PagesPerSession:        b.PagesPerSession - a.PagesPerSession,
+			NewUserPercentage:      b.NewUserPercentage - a.NewUserPercentage,
+			SessionPerUser:         b.SessionPerUser - a.SessionPerUser,
+		},
+	})
+}
+
+func dailySeries(data []common.Insight, arguments string) (string, error) {
+	var args dataFilter
+	if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+		return "", fmt.Errorf("invalid arguments: %v", err)
+	}
+	filtered, err := args.apply(data)
+	if err != nil {
+		return "", err
+	}
+	return marshalToolResult(map[string]interface{}{
+		"days": metrics.CalculateDailyMetrics(filtered),
+	})
+}
+
+// apply returns the data points that match every dimension filter and fall within the date range.
+func (f dataFilter) apply(data []common.Insight) ([]common.Insight, error) {
+	for _, filter := range f.Filters {
+		if !metrics.IsValidBreakdown(filter.Dimension) {
+			return nil, fmt.Errorf("unknown filter dimension: %s", filter.Dimension)
+		}
+		data = metrics.FilterByDimension(data, filter.Dimension, filter.Value)
+	}
+	if f.From == "" && f.To == "" {
+		return data, nil
+	}
+
+	var from, to time.Time
+	var err error
+	if f.From != "" {
+		if from, err = metrics.ParseDate(f.From); err != nil {
+			return nil, err
+		}
+	}
+	if f.To != "" {
+		if to, err = metrics.ParseDate(f.To); err != nil {
+			return nil, err
+		}
+	}
+	return metrics.FilterByDateRange(data, from, to), nil
+}
+
+func marshalToolResult(result interface{}) (string, error) {
+	content, err := json.Marshal(result)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal result: %v", err)
+	}
+	return string(content), nil
+}
+
+func objectSchema(properties map[string]interface{}, required ...string) map[string]interface{} {
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func withFilterProperties(properties map[string]interface{}) map[string]interface{} {
+	properties["filters"] = filtersSchema()
+	properties["from"] = map[string]interface{}{"type": "string", "description": "First day to include, YYYY-MM-DD."}
+	properties["to"] = map[string]interface{}{"type": "string", "description": "Last day to include, YYYY-MM-DD."}
+	return properties
+}
+
+func filtersSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "array",
+		"description": "Only include data points matching all of these dimension values.",
+		"items": objectSchema(map[string]interface{}{
+			"dimension": map[string]interface{}{"type": "string", "enum": breakdownNames},
+			"value":     map[string]interface{}{"type": "string"},
+		}, "dimension", "value"),
+	}
+}
+
+func periodSchema() map[string]interface{} {
+	return objectSchema(map[string]interface{}{
+		"from": map[string]interface{}{"type": "string", "description": "First day of the period, YYYY-MM-DD."},
+		"to":   map[string]interface{}{"type": "string", "description": "Last day of the period, YYYY-MM-DD."},
+	}, "from", "to")
+}
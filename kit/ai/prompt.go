@@ -33,6 +33,63 @@ func createPrompt(metrics common.UserMetrics) string {
 	)
 }
 
+// createAskPrompt generates the system prompt for follow-up questions about a report. It contains the report's
+// metrics and insights and instructs the model to query the raw data through the tools instead of guessing.
+func createAskPrompt(metrics common.UserMetrics, insights common.UserMetricsWithInsights) string {
+	return fmt.Sprintf(AskPromptFormat,
+		metrics.OverallMetrics.OverallEngagementRate*100,
+		metrics.OverallMetrics.AverageSessionDuration,
+		metrics.OverallMetrics.BounceRate,
+		metrics.OverallMetrics.PagesPerSession,
+		metrics.OverallMetrics.NewUserPercentage,
+		metrics.OverallMetrics.SessionPerUser,
+		formatEngagementRateList(metrics.Top5CountriesWithHighestEngagementRate),
+		formatEngagementRateList(metrics.Top5CountriesWithLowestEngagementRate),
+		formatBounceRateList(metrics.BounceRatesByDevices),
+		formatSessionCountList(metrics.Top5PagesWithHighestNoOfSessions),
+		formatSessionCountList(metrics.Top5PagesWithLowestNoOfSessions),
+		formatSessionDurationList(metrics.AverageSessionDurationsByDevices),
+		formatInsightList(insights),
+	)
+}
+
+// NewReportConversation starts a conversation about a report, with the metrics tools bound to the report's raw data.
+func NewReportConversation(client OpenAIClient, apiKey string, metrics common.UserMetrics, insights common.UserMetricsWithInsights, data []common.Insight) *Conversation {
+	return NewConversation(client, apiKey, createAskPrompt(metrics, insights), NewMetricsToolbox(data), MaxToolRounds)
+}
+
+func formatInsightList(insights common.UserMetricsWithInsights) string {
+	sections := []struct {
+		title   string
+		insight string
+	}{
+		{"Overall Metrics", insights.OverallMetrics.AIInsight},
+		{"Top 5 Countries with Highest Engagement Rate", insights.Top5CountriesWithHighestEngagementRate.AIInsight},
+		{"Top 5 Countries with Lowest Engagement Rate", insights.Top5CountriesWithLowestEngagementRate.AIInsight},
+		{"Bounce Rates by Devices", insights.BounceRatesByDevices.AIInsight},
+		{"Top 5 Pages with Highest Number of Sessions", insights.Top5PagesWithHighestNoOfSessions.AIInsight},
+		{"Top 5 Pages with Lowest Number of Sessions", insights.Top5PagesWithLowestNoOfSessions.AIInsight},
+		{"Average Session Durations by Devices", insights.AverageSessionDurationsByDevices.AIInsight},
+	}
+	var result string
+	if insights.ExecutiveSummary.Summary != "" {
+		result += fmt.Sprintf("  - Executive Summary: %s\n", insights.ExecutiveSummary.Summary)
+	}
+	for _, recommendation := range insights.ExecutiveSummary.Recommendations {
+		result += fmt.Sprintf("  - Recommendation %d (%s impact, %s confidence): %s\n",
+			recommendation.Rank, recommendation.ExpectedImpact, recommendation.Confidence, recommendation.Action)
+	}
+	for _, section := range sections {
+		if section.insight != "" {
+			result += fmt.Sprintf("  - %s: %s\n", section.title, section.insight)
+		}
+	}
+	if result == "" {
+		return "  - No insights available\n"
+	}
+	return result
+}
+
 func formatEngagementRateList(metrics []common.AggregatedMetrics) string {
 	if len(metrics) == 0 {
 		return "  - No data available\n"
@@ -106,8 +163,32 @@ Top 5 Pages with Lowest Number of Sessions:
 Average Session Durations by Devices:
 %s
 
-Please provide insights for each group of metrics as a whole in the 'ai_insight' field. The output should be in the following JSON structure without any additional words:
+Please provide insights for each group of metrics as a whole in the 'ai_insight' field. Also write a short executive
+summary of the most important takeaways in the 'executive_summary' field and a list of recommendations ranked by
+priority. Each recommendation must have an expected impact and a confidence level of "high", "medium" or "low", and
+cite the metrics above that support it as evidence. Each evidence item names the metric group ("overall_metrics" or
+one of the list keys below), the row name (omitted for overall metrics), the metric key and the value exactly as
+listed above. The output should be in the following JSON structure without any additional words:
 {
+  "executive_summary": {
+    "summary": "summary",
+    "recommendations": [
+      {
+        "rank": 1,
+        "action": "recommendation",
+        "expected_impact": "high|medium|low",
+        "confidence": "high|medium|low",
+        "evidence": [
+          {
+            "section": "metric_group",
+            "name": "row_name",
+            "metric": "metric_key",
+            "value": "value"
+          }
+        ]
+      }
+    ]
+  },
   "overall_metrics": {
     "overall_engagement_rate": "value",
     "average_session_duration": "value",
@@ -173,3 +254,43 @@ Please provide insights for each group of metrics as a whole in the 'ai_insight'
   }
 }
 `
+
+const AskPromptFormat = `
+You are an analytics assistant answering follow-up questions about a website insights report. The report was
+computed from the raw analytics data you can query with the provided tools. Only groups with at least 100 data
+points were included in the report lists.
+
+Overall Metrics:
+  - Overall Engagement Rate: %.2f%%
+  - Average Session Duration: %.2f seconds
+  - Bounce Rate: %.2f%%
+  - Pages Per Session: %.2f
+  - New User Percentage: %.2f%%
+  - Session Per User: %.2f
+
+Top 5 Countries with Highest Engagement Rate:
+%s
+Top 5 Countries with Lowest Engagement Rate:
+%s
+Bounce Rates by Devices:
+%s
+Top 5 Pages with Highest Number of Sessions:
+%s
+Top 5 Pages with Lowest Number of Sessions:
+%s
+Average Session Durations by Devices:
+%s
+Insights sent with the report:
+%s
+When a question needs numbers that are not listed above, call the tools to query the raw data instead of
+guessing. Base every claim on the report or on tool results, and say so when the data cannot answer a question.
+Answer concisely in plain text.
+`
+
+const InsightsToolPrompt = `
+You are a web analytics expert writing insights for a website report. You can call the provided tools to query
+the raw analytics data behind the metrics: aggregate it by a breakdown, filter it by dimension values, compare two
+periods and get the daily series. Before writing the insights, use the tools to look into anything surprising,
+such as an unusually low engagement rate or a large gap between devices, and use what you find in the insights.
+Your final reply must contain only the requested JSON, without any additional words.
+`
@@ -0,0 +1,60 @@
+package ai
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// RateLimiter spaces requests evenly so that no more than a given number start per minute. It is safe for
+// concurrent use, so that the workers processing files share the provider's limit.
+type RateLimiter struct {
+	interval time.Duration
+	mu       sync.Mutex
+	next     time.Time
+}
+
+func NewRateLimiter(requestsPerMinute int) *RateLimiter {
+	return &RateLimiter{interval: time.Minute / time.Duration(requestsPerMinute)}
+}
+
+// Reserve returns how long the caller must wait before its request may start, and reserves that slot.
+func (l *RateLimiter) Reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	wait := l.next.Sub(now)
+	l.next = l.next.Add(l.interval)
+	return wait
+}
+
+// rateLimitedTransport waits for the rate limiter before every request.
+type rateLimitedTransport struct {
+	limiter *RateLimiter
+	base    http.RoundTripper
+}
+
+func (t rateLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if wait := t.limiter.Reserve(); wait > 0 {
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+	return t.base.RoundTrip(req)
+}
+
+// NewHTTPClient returns the HTTP client for the LLM provider. With a positive requestsPerMinute every request,
+// including each round of tool calls, waits for its turn so that the limit is never exceeded.
+func NewHTTPClient(requestsPerMinute int) *http.Client {
+	if requestsPerMinute <= 0 {
+		return &http.Client{}
+	}
+	return &http.Client{Transport: rateLimitedTransport{limiter: NewRateLimiter(requestsPerMinute), base: http.DefaultTransport}}
+}
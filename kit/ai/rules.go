@@ -0,0 +1,358 @@
+package ai
+
+import (
+	"data-insights/kit/common"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"unicode"
+)
+
+const (
+	highBounceRate                 = 50
+	notableBounceRateGap           = 10
+	largeBounceRateGap             = 15
+	notableEngagementRateGap       = 10
+	shortSessionDurationRatio      = 0.5
+	highConfidenceDataPointCount   = 500
+	mediumConfidenceDataPointCount = 100
+)
+
+// RuleBasedClient generates insights from the computed metrics with templated sentences. It needs no network
+// access, so it is used as a fallback when the LLM is unavailable and as the engine for air-gapped deployments.
+type RuleBasedClient struct{}
+
+func NewRuleBasedClient() RuleBasedClient {
+	return RuleBasedClient{}
+}
+
+// Model returns "rules", since no model writes the rule-based insights.
+func (r RuleBasedClient) Model() string {
+	return string(EngineRules)
+}
+
+// GetInsightsFromLLM returns the rule-based insights in the same JSON structure the LLM is asked to produce.
+// The API key is ignored.
+func (r RuleBasedClient) GetInsightsFromLLM(_ string, data common.UserMetrics) (string, error) {
+	content, err := json.Marshal(r.GenerateInsights(data))
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal rule-based insights: %v", err)
+	}
+	return string(content), nil
+}
+
+// GenerateInsights fills every section of UserMetricsWithInsights from the computed metrics.
+func (r RuleBasedClient) GenerateInsights(data common.UserMetrics) common.UserMetricsWithInsights {
+	overall := data.OverallMetrics
+	overallRate := overall.OverallEngagementRate * 100
+
+	return common.UserMetricsWithInsights{
+		ExecutiveSummary: executiveSummary(data, overallRate),
+		OverallMetrics: common.OverallMetricsWithInsight{
+			OverallEngagementRate:  formatPercent(overallRate),
+			AverageSessionDuration: formatSeconds(overall.AverageSessionDuration),
+			BounceRate:             formatPercent(overall.BounceRate),
+			PagesPerSession:        fmt.Sprintf("%.2f", overall.PagesPerSession),
+			NewUserPercentage:      formatPercent(overall.NewUserPercentage),
+			SessionPerUser:         fmt.Sprintf("%.2f", overall.SessionPerUser),
+			AIInsight:              overallInsight(overall),
+		},
+		Top5CountriesWithHighestEngagementRate: common.AggregatedMetricsWithInsight{
+			AIInsight:         highestEngagementInsight(data.Top5CountriesWithHighestEngagementRate, overallRate),
+			AggregatedMetrics: engagementRateRows(data.Top5CountriesWithHighestEngagementRate),
+		},
+		Top5CountriesWithLowestEngagementRate: common.AggregatedMetricsWithInsight{
+			AIInsight:         lowestEngagementInsight(data.Top5CountriesWithLowestEngagementRate, overallRate),
+			AggregatedMetrics: engagementRateRows(data.Top5CountriesWithLowestEngagementRate),
+		},
+		BounceRatesByDevices: common.AggregatedMetricsWithInsight{
+			AIInsight:         bounceRateInsight(data.BounceRatesByDevices),
+			AggregatedMetrics: bounceRateRows(data.BounceRatesByDevices),
+		},
+		Top5PagesWithHighestNoOfSessions: common.AggregatedMetricsWithInsight{
+			AIInsight:         highestSessionsInsight(data.Top5PagesWithHighestNoOfSessions),
+			AggregatedMetrics: sessionCountRows(data.Top5PagesWithHighestNoOfSessions),
+		},
+		Top5PagesWithLowestNoOfSessions: common.AggregatedMetricsWithInsight{
+			AIInsight:         lowestSessionsInsight(data.Top5PagesWithLowestNoOfSessions),
+			AggregatedMetrics: sessionCountRows(data.Top5PagesWithLowestNoOfSessions),
+		},
+		AverageSessionDurationsByDevices: common.AggregatedMetricsWithInsight{
+			AIInsight:         sessionDurationInsight(data.AverageSessionDurationsByDevices),
+			AggregatedMetrics: sessionDurationRows(data.AverageSessionDurationsByDevices),
+		},
+	}
+}
+
+// executiveSummary ranks templated recommendations for the conditions that stand out in the metrics, such as a
+// large bounce rate gap between devices, and summarises the overall metrics and the top recommendation.
+func executiveSummary(data common.UserMetrics, overallRate float64) common.ExecutiveSummary {
+	overall := data.OverallMetrics
+	var recommendations []common.Recommendation
+
+	if devices := data.BounceRatesByDevices; len(devices) > 1 {
+		highest, lowest := devices[0], devices[0]
+		for _, device := range devices[1:] {
+			if device.BounceRate > highest.BounceRate {
+				highest = device
+			}
+			if device.BounceRate < lowest.BounceRate {
+				lowest = device
+			}
+		}
+		if gap := highest.BounceRate - lowest.BounceRate; gap >= notableBounceRateGap {
+			impact := LevelMedium
+			if gap >= largeBounceRateGap {
+				impact = LevelHigh
+			}
+			recommendations = append(recommendations, common.Recommendation{
+				Action:         fmt.Sprintf("Improve the %s experience to bring its bounce rate closer to %s.", highest.Name, lowest.Name),
+				ExpectedImpact: impact,
+				Confidence:     confidenceLevel(highest.DataPointCount),
+				Evidence: []common.Evidence{
+					{Section: common.BOUNCERATESSECTION, Name: highest.Name, Metric: "bounce_rate", Value: formatPercent(highest.BounceRate)},
+					{Section: common.BOUNCERATESSECTION, Name: lowest.Name, Metric: "bounce_rate", Value: formatPercent(lowest.BounceRate)},
+				},
+			})
+		}
+	}
+
+	if countries := data.Top5CountriesWithLowestEngagementRate; len(countries) > 0 {
+		lowest := countries[0]
+		if rate := lowest.AverageEngagementRate * 100; overallRate-rate >= notableEngagementRateGap {
+			recommendations = append(recommendations, common.Recommendation{
+				Action:         fmt.Sprintf("Review localisation and page speed for visitors from %s.", lowest.Name),
+				ExpectedImpact: LevelMedium,
+				Confidence:     confidenceLevel(lowest.DataPointCount),
+				Evidence: []common.Evidence{
+					{Section: common.BOTTOMCOUNTRIESSECTION, Name: lowest.Name, Metric: "average_engagement_rate", Value: formatPercent(rate)},
+					{Section: common.OVERALLSECTION, Metric: "overall_engagement_rate", Value: formatPercent(overallRate)},
+				},
+			})
+		}
+	}
+
+	if mediums := data.AverageSessionDurationsByDevices; len(mediums) > 1 {
+		longest, shortest := mediums[0], mediums[0]
+		for _, medium := range mediums[1:] {
+			if medium.AverageSessionDuration > longest.AverageSessionDuration {
+				longest = medium
+			}
+			if medium.AverageSessionDuration < shortest.AverageSessionDuration {
+				shortest = medium
+			}
+		}
+		if shortest.AverageSessionDuration < longest.AverageSessionDuration*shortSessionDurationRatio {
+			recommendations = append(recommendations, common.Recommendation{
+				Action:         fmt.Sprintf("Review the landing pages that %s traffic arrives on.", shortest.Name),
+				ExpectedImpact: LevelMedium,
+				Confidence:     confidenceLevel(shortest.DataPointCount),
+				Evidence: []common.Evidence{
+					{Section: common.SESSIONDURATIONSSECTION, Name: shortest.Name, Metric: "average_session_duration", Value: formatSeconds(shortest.AverageSessionDuration)},
+					{Section: common.SESSIONDURATIONSSECTION, Name: longest.Name, Metric: "average_session_duration", Value: formatSeconds(longest.AverageSessionDuration)},
+				},
+			})
+		}
+	}
+
+	if overall.BounceRate > highBounceRate {
+		recommendations = append(recommendations, common.Recommendation{
+			Action:         "Add clear next steps to the main landing pages to reduce single-page sessions.",
+			ExpectedImpact: LevelHigh,
+			Confidence:     LevelMedium,
+			Evidence: []common.Evidence{
+				{Section: common.OVERALLSECTION, Metric: "bounce_rate", Value: formatPercent(overall.BounceRate)},
+			},
+		})
+	}
+
+	// High impact recommendations first, keeping the order above among equals.
+	sort.SliceStable(recommendations, func(i, j int) bool {
+		return recommendations[i].ExpectedImpact == LevelHigh && recommendations[j].ExpectedImpact != LevelHigh
+	})
+	for i := range recommendations {
+		recommendations[i].Rank = i + 1
+	}
+
+	summary := fmt.Sprintf("Engagement rate is %s with %.2f pages per session and a bounce rate of %s.",
+		formatPercent(overallRate), overall.PagesPerSession, formatPercent(overall.BounceRate))
+	if len(recommendations) > 0 {
+		summary += " The top priority: " + recommendations[0].Action
+	} else {
+		summary += " No metric stands out from the rest of the report."
+	}
+
+	return common.ExecutiveSummary{
+		Summary:         summary,
+		Recommendations: recommendations,
+	}
+}
+
+// confidenceLevel derives the confidence of a recommendation from the number of data points behind it.
+func confidenceLevel(dataPointCount int) string {
+	switch {
+	case dataPointCount >= highConfidenceDataPointCount:
+		return LevelHigh
+	case dataPointCount >= mediumConfidenceDataPointCount:
+		return LevelMedium
+	default:
+		return LevelLow
+	}
+}
+
+func overallInsight(overall common.OverallMetrics) string {
+	insight := fmt.Sprintf("Overall engagement rate is %s with a bounce rate of %s. Visitors view %.2f pages per session on average and %s of users are new.",
+		formatPercent(overall.OverallEngagementRate*100), formatPercent(overall.BounceRate), overall.PagesPerSession, formatPercent(overall.NewUserPercentage))
+	if overall.BounceRate > highBounceRate {
+		insight += " More than half of the sessions end after a single page."
+	}
+	return insight
+}
+
+func highestEngagementInsight(metrics []common.AggregatedMetrics, overallRate float64) string {
+	if len(metrics) == 0 {
+		return "There is not enough data to rank countries by engagement rate."
+	}
+	top := metrics[0]
+	rate := top.AverageEngagementRate * 100
+	return fmt.Sprintf("%s leads engagement at %s, %s the overall rate of %s.",
+		top.Name, formatPercent(rate), formatPointDifference(rate, overallRate), formatPercent(overallRate))
+}
+
+func lowestEngagementInsight(metrics []common.AggregatedMetrics, overallRate float64) string {
+	if len(metrics) == 0 {
+		return "There is not enough data to rank countries by engagement rate."
+	}
+	bottom := metrics[0]
+	rate := bottom.AverageEngagementRate * 100
+	return fmt.Sprintf("%s has the lowest engagement at %s, %s the overall rate of %s.",
+		bottom.Name, formatPercent(rate), formatPointDifference(rate, overallRate), formatPercent(overallRate))
+}
+
+func bounceRateInsight(metrics []common.AggregatedMetrics) string {
+	switch len(metrics) {
+	case 0:
+		return "There is not enough data to compare bounce rates across devices."
+	case 1:
+		return fmt.Sprintf("Only %s has enough data, with a bounce rate of %s.", metrics[0].Name, formatPercent(metrics[0].BounceRate))
+	}
+	highest, lowest := metrics[0], metrics[0]
+	for _, metric := range metrics[1:] {
+		if metric.BounceRate > highest.BounceRate {
+			highest = metric
+		}
+		if metric.BounceRate < lowest.BounceRate {
+			lowest = metric
+		}
+	}
+	return fmt.Sprintf("%s bounce rate is %s %s (%s vs %s).",
+		capitalize(highest.Name), formatPointDifference(highest.BounceRate, lowest.BounceRate), lowest.Name,
+		formatPercent(highest.BounceRate), formatPercent(lowest.BounceRate))
+}
+
+func highestSessionsInsight(metrics []common.AggregatedMetrics) string {
+	if len(metrics) == 0 {
+		return "There is not enough data to rank pages by number of sessions."
+	}
+	var total int
+	for _, metric := range metrics {
+		total += metric.TotalSessions
+	}
+	top := metrics[0]
+	if total == 0 {
+		return fmt.Sprintf("%s leads the listed pages, but none of them recorded any sessions.", top.Name)
+	}
+	return fmt.Sprintf("%s attracts the most sessions with %d, %s of the sessions across the listed pages.",
+		top.Name, top.TotalSessions, formatPercent(float64(top.TotalSessions)/float64(total)*100))
+}
+
+func lowestSessionsInsight(metrics []common.AggregatedMetrics) string {
+	if len(metrics) == 0 {
+		return "There is not enough data to rank pages by number of sessions."
+	}
+	bottom := metrics[0]
+	return fmt.Sprintf("%s has the fewest sessions with %d among the pages that meet the data threshold.", bottom.Name, bottom.TotalSessions)
+}
+
+func sessionDurationInsight(metrics []common.AggregatedMetrics) string {
+	switch len(metrics) {
+	case 0:
+		return "There is not enough data to compare average session durations."
+	case 1:
+		return fmt.Sprintf("Only %s has enough data, with an average session duration of %s.", metrics[0].Name, formatSeconds(metrics[0].AverageSessionDuration))
+	}
+	longest, shortest := metrics[0], metrics[0]
+	for _, metric := range metrics[1:] {
+		if metric.AverageSessionDuration > longest.AverageSessionDuration {
+			longest = metric
+		}
+		if metric.AverageSessionDuration < shortest.AverageSessionDuration {
+			shortest = metric
+		}
+	}
+	return fmt.Sprintf("%s sessions last the longest at %s, compared with %s for %s.",
+		capitalize(longest.Name), formatSeconds(longest.AverageSessionDuration), formatSeconds(shortest.AverageSessionDuration), shortest.Name)
+}
+
+func engagementRateRows(metrics []common.AggregatedMetrics) []common.AggregatedMetric {
+	rows := make([]common.AggregatedMetric, 0, len(metrics))
+	for _, metric := range metrics {
+		rows = append(rows, common.AggregatedMetric{Name: metric.Name, AverageEngagementRate: formatPercent(metric.AverageEngagementRate * 100)})
+	}
+	return rows
+}
+
+func bounceRateRows(metrics []common.AggregatedMetrics) []common.AggregatedMetric {
+	rows := make([]common.AggregatedMetric, 0, len(metrics))
+	for _, metric := range metrics {
+		rows = append(rows, common.AggregatedMetric{Name: metric.Name, BounceRate: formatPercent(metric.BounceRate)})
+	}
+	return rows
+}
+
+func sessionCountRows(metrics []common.AggregatedMetrics) []common.AggregatedMetric {
+	rows := make([]common.AggregatedMetric, 0, len(metrics))
+	for _, metric := range metrics {
+		rows = append(rows, common.AggregatedMetric{Name: metric.Name, TotalSessions: fmt.Sprintf("%d", metric.TotalSessions)})
+	}
+	return rows
+}
+
+func sessionDurationRows(metrics []common.AggregatedMetrics) []common.AggregatedMetric {
+	rows := make([]common.AggregatedMetric, 0, len(metrics))
+	for _, metric := range metrics {
+		rows = append(rows, common.AggregatedMetric{Name: metric.Name, AverageSessionDuration: formatSeconds(metric.AverageSessionDuration)})
+	}
+	return rows
+}
+
+func formatPercent(value float64) string {
+	return fmt.Sprintf("%.2f%%", value)
+}
+
+func formatSeconds(value float64) string {
+	return fmt.Sprintf("%.2f seconds", value)
+}
+
+// formatPointDifference describes value relative to reference in percentage points, e.g. "12.30 points above".
+func formatPointDifference(value, reference float64) string {
+	difference := value - reference
+	switch {
+	case math.Abs(difference) < 0.005:
+		return "in line with"
+	case difference > 0:
+		return fmt.Sprintf("%.2f points above", difference)
+	default:
+		return fmt.Sprintf("%.2f points below", -difference)
+	}
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	runes := []rune(s)
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
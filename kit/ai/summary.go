@@ -0,0 +1,49 @@
+package ai
+
+import (
+	"data-insights/kit/common"
+	"data-insights/kit/metrics"
+	"log"
+	"sort"
+)
+
+// VerifyExecutiveSummary checks every piece of recommendation evidence against the computed metrics and marks it
+// as verified or not, regardless of what the model claimed. Recommendations without any verified evidence are
+// dropped, and the remaining ones are ordered by rank and renumbered from one.
+func VerifyExecutiveSummary(summary common.ExecutiveSummary, userMetrics common.UserMetrics) common.ExecutiveSummary {
+	var recommendations []common.Recommendation
+	for _, recommendation := range summary.Recommendations {
+		var verified int
+		for i, evidence := range recommendation.Evidence {
+			recommendation.Evidence[i].Verified = verifyEvidence(evidence, userMetrics)
+			if recommendation.Evidence[i].Verified {
+				verified++
+			}
+		}
+		if verified == 0 {
+			log.Printf("dropping recommendation without verified evidence: %s", recommendation.Action)
+			continue
+		}
+		recommendations = append(recommendations, recommendation)
+	}
+
+	sort.SliceStable(recommendations, func(i, j int) bool { return recommendations[i].Rank < recommendations[j].Rank })
+	for i := range recommendations {
+		recommendations[i].Rank = i + 1
+	}
+
+	summary.Recommendations = recommendations
+	return summary
+}
+
+func verifyEvidence(evidence common.Evidence, userMetrics common.UserMetrics) bool {
+	expected, isRate, ok := metrics.LookupValue(userMetrics, evidence.Section, evidence.Name, evidence.Metric)
+	if !ok {
+		return false
+	}
+	got, ok := metrics.ParseNumber(evidence.Value)
+	if !ok {
+		return false
+	}
+	return metrics.ValueMatches(got, expected, isRate, EvidenceTolerance)
+}
@@ -0,0 +1,49 @@
+package ai
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ToolHandler executes a tool call with the JSON arguments chosen by the model and returns a JSON result.
+type ToolHandler func(arguments string) (string, error)
+
+// Toolbox holds the tools offered to the model and dispatches the model's tool calls to their handlers.
+type Toolbox struct {
+	tools    []Tool
+	handlers map[string]ToolHandler
+}
+
+func NewToolbox() *Toolbox {
+	return &Toolbox{handlers: make(map[string]ToolHandler)}
+}
+
+// Register adds a tool definition and the handler that executes it.
+func (t *Toolbox) Register(function ToolFunction, handler ToolHandler) {
+	t.tools = append(t.tools, Tool{Type: OpenAIToolType, Function: function})
+	t.handlers[function.Name] = handler
+}
+
+// Definitions returns the tool definitions in the format expected by the chat completions API.
+func (t *Toolbox) Definitions() []Tool {
+	return t.tools
+}
+
+// Call executes a tool call. Errors are returned to the model as a JSON error object rather than failing the
+// conversation, so that the model can correct its arguments.
+func (t *Toolbox) Call(call ToolCall) string {
+	handler, ok := t.handlers[call.Function.Name]
+	if !ok {
+		return toolError(fmt.Errorf("unknown tool: %s", call.Function.Name))
+	}
+	result, err := handler(call.Function.Arguments)
+	if err != nil {
+		return toolError(err)
+	}
+	return result
+}
+
+func toolError(err error) string {
+	content, _ := json.Marshal(map[string]string{"error": err.Error()})
+	return string(content)
+}
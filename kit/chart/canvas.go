@@ -0,0 +1,17 @@
+package chart
+
+// canvas is the drawing surface that charts are laid out on, implemented by the SVG and PNG encoders.
+// Coordinates are in pixels from the top-left corner; text is positioned by its baseline.
+type canvas interface {
+	rect(x, y, width, height float64, colour string)
+	polyline(points []point, colour string, width float64)
+	text(x, y float64, value string, size int, bold bool, anchor anchor, colour string)
+	encode() ([]byte, error)
+}
+
+func newCanvas(format Format, width, height int) canvas {
+	if format == FormatSVG {
+		return newSVGCanvas(width, height)
+	}
+	return newPNGCanvas(width, height)
+}
@@ -0,0 +1,119 @@
+package chart
+
+import (
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"math"
+)
+
+// NewBarChart renders a horizontal bar chart with one labelled row per bar, scaled to the largest value.
+func NewBarChart(title string, bars []Bar, format Format) ([]byte, error) {
+	height := TitleHeight + len(bars)*BarRowHeight + Padding
+	c := newCanvas(format, Width, height)
+	c.text(Padding, TitleHeight-Padding, title, TitleFontSize, true, anchorStart, TextColour)
+
+	maxValue := 0.0
+	for _, bar := range bars {
+		maxValue = math.Max(maxValue, bar.Value)
+	}
+	if maxValue == 0 {
+		maxValue = 1
+	}
+
+	barX := float64(LabelWidth + Padding)
+	barArea := float64(Width-ValueWidth) - barX
+	for i, bar := range bars {
+		top := float64(TitleHeight + i*BarRowHeight)
+		baseline := top + BarRowHeight/2 + FontSize/3
+		barWidth := math.Max(barArea*math.Max(bar.Value, 0)/maxValue, 1)
+
+		c.text(LabelWidth, baseline, shorten(bar.Label, MaxLabelLength), FontSize, false, anchorEnd, TextColour)
+		c.rect(barX, top+(BarRowHeight-BarHeight)/2, barWidth, BarHeight, BarColour)
+		c.text(barX+barWidth+Padding/2, baseline, bar.Display, FontSize, false, anchorStart, TextColour)
+	}
+	return c.encode()
+}
+
+// NewSparkline renders the values as a line, labelled with the first and last point and the value range.
+// At least two points are required.
+func NewSparkline(title string, points []Point, format Format) ([]byte, error) {
+	if len(points) < 2 {
+		return nil, fmt.Errorf("a sparkline needs at least two points, got %d", len(points))
+	}
+	c := newCanvas(format, Width, SparklineHeight)
+	c.text(Padding, TitleHeight-Padding, title, TitleFontSize, true, anchorStart, TextColour)
+
+	minValue, maxValue := points[0].Value, points[0].Value
+	for _, p := range points {
+		minValue = math.Min(minValue, p.Value)
+		maxValue = math.Max(maxValue, p.Value)
+	}
+	valueRange := maxValue - minValue
+	if valueRange == 0 {
+		valueRange = 1
+	}
+
+	left, right := float64(ValueWidth), float64(Width-Padding)
+	top, bottom := float64(TitleHeight+Padding), float64(SparklineHeight-2*Padding-FontSize)
+	c.rect(left, bottom, right-left, 1, AxisColour)
+
+	line := make([]point, 0, len(points))
+	for i, p := range points {
+		line = append(line, point{
+			X: left + (right-left)*float64(i)/float64(len(points)-1),
+			Y: bottom - (bottom-top)*(p.Value-minValue)/valueRange,
+		})
+	}
+	c.polyline(line, LineColour, LineWidth)
+
+	c.text(left-Padding/2, top+FontSize/2, formatNumber(maxValue), FontSize, false, anchorEnd, TextColour)
+	c.text(left-Padding/2, bottom, formatNumber(minValue), FontSize, false, anchorEnd, TextColour)
+	c.text(left, SparklineHeight-Padding, points[0].Label, FontSize, false, anchorStart, TextColour)
+	c.text(right, SparklineHeight-Padding, points[len(points)-1].Label, FontSize, false, anchorEnd, TextColour)
+	return c.encode()
+}
+
+// ContentType returns the MIME type of the chart image.
+func (c Chart) ContentType() string {
+	if c.Format == FormatSVG {
+		return "image/svg+xml"
+	}
+	return "image/png"
+}
+
+// Filename returns the attachment file name of the chart, named after its section.
+func (c Chart) Filename() string {
+	return fmt.Sprintf("%s.%s", c.Section, c.Format)
+}
+
+// ContentID returns the Content-ID under which the chart is attached inline to an email.
+func (c Chart) ContentID() string {
+	return fmt.Sprintf("%s@%s", c.Section, ContentIDDomain)
+}
+
+// CID returns the URL referencing the chart's inline email attachment.
+func (c Chart) CID() template.URL {
+	return template.URL("cid:" + c.ContentID())
+}
+
+// DataURI returns the chart as a base64 data URI, for HTML documents without attachments.
+func (c Chart) DataURI() template.URL {
+	return template.URL("data:" + c.ContentType() + ";base64," + base64.StdEncoding.EncodeToString(c.Data))
+}
+
+// shorten truncates text to at most limit characters, ending it with an ellipsis when it is cut.
+func shorten(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return string(runes[:limit-3]) + "..."
+}
+
+func formatNumber(value float64) string {
+	if value == math.Trunc(value) {
+		return fmt.Sprintf("%.0f", value)
+	}
+	return fmt.Sprintf("%.2f", value)
+}
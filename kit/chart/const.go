@@ -0,0 +1,30 @@
+package chart
+
+type Format string
+
+const (
+	FormatPNG  Format = "png"
+	FormatSVG  Format = "svg"
+	FormatNone Format = "none" // no charts
+)
+
+const (
+	Width            = 560
+	TitleHeight      = 30
+	BarRowHeight     = 28
+	BarHeight        = 18
+	LabelWidth       = 170
+	ValueWidth       = 80
+	SparklineHeight  = 120
+	Padding          = 10
+	MaxLabelLength   = 24
+	FontSize         = 12
+	TitleFontSize    = 14
+	LineWidth        = 2
+	ContentIDDomain  = "data-insights"
+	BackgroundColour = "#ffffff"
+	BarColour        = "#4e79a7"
+	LineColour       = "#e15759"
+	TextColour       = "#333333"
+	AxisColour       = "#dddddd"
+)
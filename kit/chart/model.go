@@ -0,0 +1,35 @@
+package chart
+
+import "data-insights/kit/common"
+
+// Chart is a rendered chart image for one report section.
+type Chart struct {
+	Section common.Section
+	Title   string
+	Format  Format
+	Data    []byte
+}
+
+// Bar is one bar of a bar chart. Display is the formatted value printed next to the bar.
+type Bar struct {
+	Label   string
+	Value   float64
+	Display string
+}
+
+// Point is one point of a sparkline.
+type Point struct {
+	Label string
+	Value float64
+}
+
+type point struct {
+	X, Y float64
+}
+
+type anchor string
+
+const (
+	anchorStart anchor = "start"
+	anchorEnd   anchor = "end"
+)
@@ -0,0 +1,83 @@
+package chart
+
+import (
+	"bytes"
+	"fmt"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"strconv"
+)
+
+type pngCanvas struct {
+	img *image.RGBA
+}
+
+func newPNGCanvas(width, height int) *pngCanvas {
+	c := &pngCanvas{img: image.NewRGBA(image.Rect(0, 0, width, height))}
+	c.rect(0, 0, float64(width), float64(height), BackgroundColour)
+	return c
+}
+
+func (c *pngCanvas) rect(x, y, width, height float64, colour string) {
+	bounds := image.Rect(int(math.Round(x)), int(math.Round(y)), int(math.Round(x+width)), int(math.Round(y+height)))
+	draw.Draw(c.img, bounds, image.NewUniform(parseColour(colour)), image.Point{}, draw.Src)
+}
+
+// polyline draws every segment by stamping squares of the line width along it.
+func (c *pngCanvas) polyline(points []point, colour string, width float64) {
+	for i := 1; i < len(points); i++ {
+		from, to := points[i-1], points[i]
+		steps := int(math.Max(math.Abs(to.X-from.X), math.Abs(to.Y-from.Y))) + 1
+		for step := 0; step <= steps; step++ {
+			t := float64(step) / float64(steps)
+			x := from.X + (to.X-from.X)*t
+			y := from.Y + (to.Y-from.Y)*t
+			c.rect(x-width/2, y-width/2, width, width, colour)
+		}
+	}
+}
+
+// text draws the value with the built-in 7x13 bitmap font; the size is ignored and bold text is drawn twice,
+// one pixel apart.
+func (c *pngCanvas) text(x, y float64, value string, size int, bold bool, anchor anchor, colour string) {
+	drawer := &font.Drawer{
+		Dst:  c.img,
+		Src:  image.NewUniform(parseColour(colour)),
+		Face: basicfont.Face7x13,
+	}
+	if anchor == anchorEnd {
+		x -= float64(drawer.MeasureString(value).Round())
+	}
+	drawer.Dot = fixed.P(int(math.Round(x)), int(math.Round(y)))
+	drawer.DrawString(value)
+	if bold {
+		drawer.Dot = fixed.P(int(math.Round(x))+1, int(math.Round(y)))
+		drawer.DrawString(value)
+	}
+}
+
+func (c *pngCanvas) encode() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, c.img); err != nil {
+		return nil, fmt.Errorf("failed to encode png: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// parseColour parses a #rrggbb colour, falling back to black.
+func parseColour(hex string) color.RGBA {
+	if len(hex) != 7 || hex[0] != '#' {
+		return color.RGBA{A: 255}
+	}
+	value, err := strconv.ParseUint(hex[1:], 16, 32)
+	if err != nil {
+		return color.RGBA{A: 255}
+	}
+	return color.RGBA{R: uint8(value >> 16), G: uint8(value >> 8), B: uint8(value), A: 255}
+}
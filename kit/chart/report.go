@@ -0,0 +1,84 @@
+package chart
+
+import (
+	"data-insights/kit/common"
+	"fmt"
+	"strconv"
+)
+
+// ReportCharts renders the charts for the report sections: bar charts for the country and page lists and the
+// device breakdowns, and a sparkline of the daily sessions for the overall section when the data spans more
+// than one day. No charts are rendered in the none format.
+func ReportCharts(userMetrics common.UserMetrics, daily []common.DailyMetrics, format Format) ([]Chart, error) {
+	switch format {
+	case FormatNone:
+		return nil, nil
+	case "":
+		format = FormatPNG
+	case FormatPNG, FormatSVG:
+	default:
+		return nil, fmt.Errorf("unknown chart format: %s", format)
+	}
+
+	var charts []Chart
+	barCharts := []struct {
+		section common.Section
+		title   string
+		metrics common.AggregatedMetricsList
+		value   func(common.AggregatedMetrics) (float64, string)
+	}{
+		{common.TOPCOUNTRIESSECTION, "Countries with highest engagement rate", userMetrics.Top5CountriesWithHighestEngagementRate, engagementRate},
+		{common.BOTTOMCOUNTRIESSECTION, "Countries with lowest engagement rate", userMetrics.Top5CountriesWithLowestEngagementRate, engagementRate},
+		{common.BOUNCERATESSECTION, "Bounce rate by device", userMetrics.BounceRatesByDevices, bounceRate},
+		{common.TOPPAGESSECTION, "Pages with most sessions", userMetrics.Top5PagesWithHighestNoOfSessions, totalSessions},
+		{common.BOTTOMPAGESSECTION, "Pages with fewest sessions", userMetrics.Top5PagesWithLowestNoOfSessions, totalSessions},
+		{common.SESSIONDURATIONSSECTION, "Average session duration by device", userMetrics.AverageSessionDurationsByDevices, sessionDuration},
+	}
+	for _, barChart := range barCharts {
+		if len(barChart.metrics) == 0 {
+			continue
+		}
+		bars := make([]Bar, 0, len(barChart.metrics))
+		for _, metric := range barChart.metrics {
+			value, display := barChart.value(metric)
+			bars = append(bars, Bar{Label: metric.Name, Value: value, Display: display})
+		}
+		data, err := NewBarChart(barChart.title, bars, format)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render %s chart: %v", barChart.section, err)
+		}
+		charts = append(charts, Chart{Section: barChart.section, Title: barChart.title, Format: format, Data: data})
+	}
+
+	if len(daily) > 1 {
+		title := "Daily sessions"
+		points := make([]Point, 0, len(daily))
+		for _, day := range daily {
+			points = append(points, Point{Label: day.Date, Value: float64(day.TotalSessions)})
+		}
+		data, err := NewSparkline(title, points, format)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render %s chart: %v", common.OVERALLSECTION, err)
+		}
+		charts = append(charts, Chart{Section: common.OVERALLSECTION, Title: title, Format: format, Data: data})
+	}
+	return charts, nil
+}
+
+// engagementRate returns the engagement rate as a percentage; it is stored as a fraction.
+func engagementRate(metric common.AggregatedMetrics) (float64, string) {
+	rate := metric.AverageEngagementRate * 100
+	return rate, fmt.Sprintf("%.2f%%", rate)
+}
+
+func bounceRate(metric common.AggregatedMetrics) (float64, string) {
+	return metric.BounceRate, fmt.Sprintf("%.2f%%", metric.BounceRate)
+}
+
+func totalSessions(metric common.AggregatedMetrics) (float64, string) {
+	return float64(metric.TotalSessions), strconv.Itoa(metric.TotalSessions)
+}
+
+func sessionDuration(metric common.AggregatedMetrics) (float64, string) {
+	return metric.AverageSessionDuration, fmt.Sprintf("%.2fs", metric.AverageSessionDuration)
+}
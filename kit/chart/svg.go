@@ -0,0 +1,45 @@
+package chart
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+type svgCanvas struct {
+	width, height int
+	elements      strings.Builder
+}
+
+func newSVGCanvas(width, height int) *svgCanvas {
+	c := &svgCanvas{width: width, height: height}
+	c.rect(0, 0, float64(width), float64(height), BackgroundColour)
+	return c
+}
+
+func (c *svgCanvas) rect(x, y, width, height float64, colour string) {
+	fmt.Fprintf(&c.elements, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s"/>`, x, y, width, height, colour)
+}
+
+func (c *svgCanvas) polyline(points []point, colour string, width float64) {
+	coordinates := make([]string, 0, len(points))
+	for _, p := range points {
+		coordinates = append(coordinates, fmt.Sprintf("%.1f,%.1f", p.X, p.Y))
+	}
+	fmt.Fprintf(&c.elements, `<polyline points="%s" fill="none" stroke="%s" stroke-width="%.1f" stroke-linejoin="round"/>`,
+		strings.Join(coordinates, " "), colour, width)
+}
+
+func (c *svgCanvas) text(x, y float64, value string, size int, bold bool, anchor anchor, colour string) {
+	weight := "normal"
+	if bold {
+		weight = "bold"
+	}
+	fmt.Fprintf(&c.elements, `<text x="%.1f" y="%.1f" font-family="Arial, sans-serif" font-size="%d" font-weight="%s" text-anchor="%s" fill="%s">%s</text>`,
+		x, y, size, weight, anchor, colour, html.EscapeString(value))
+}
+
+func (c *svgCanvas) encode() ([]byte, error) {
+	return []byte(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">%s</svg>`,
+		c.width, c.height, c.width, c.height, c.elements.String())), nil
+}
@@ -25,3 +25,25 @@ const (
 	AVGENGAGEMENTDURATION Metric = "AverageEngagementDuration"
 	DATAPOINTCOUNT        Metric = "DataPointCount"
 )
+
+const DateLayout = "20060102"
+
+type Section string
+
+// Section names match the JSON keys of UserMetricsWithInsights.
+const (
+	SUMMARYSECTION          Section = "executive_summary"
+	OVERALLSECTION          Section = "overall_metrics"
+	TOPCOUNTRIESSECTION     Section = "top_5_countries_with_highest_engagement_rate"
+	BOTTOMCOUNTRIESSECTION  Section = "top_5_countries_with_lowest_engagement_rate"
+	BOUNCERATESSECTION      Section = "bounce_rates_by_devices"
+	TOPPAGESSECTION         Section = "top_5_pages_with_highest_no_of_sessions"
+	BOTTOMPAGESSECTION      Section = "top_5_pages_with_lowest_no_of_sessions"
+	SESSIONDURATIONSSECTION Section = "average_session_durations_by_devices"
+)
+
+// SECTIONS lists every report section in the order it is rendered.
+var SECTIONS = []Section{
+	SUMMARYSECTION, OVERALLSECTION, TOPCOUNTRIESSECTION, BOTTOMCOUNTRIESSECTION,
+	BOUNCERATESSECTION, TOPPAGESSECTION, BOTTOMPAGESSECTION, SESSIONDURATIONSSECTION,
+}
@@ -1,5 +1,10 @@
 package common
 
+import (
+	"html/template"
+	"time"
+)
+
 type Insight struct {
 	Country                string `json:"Country"`
 	DeviceCategory         string `json:"DeviceCategory"`
@@ -38,6 +43,12 @@ type OverallMetrics struct {
 	SessionPerUser         float64
 }
 
+type DailyMetrics struct {
+	Date          string
+	TotalSessions int
+	OverallMetrics
+}
+
 type UserMetrics struct {
 	OverallMetrics                         OverallMetrics
 	Top5CountriesWithHighestEngagementRate AggregatedMetricsList
@@ -71,7 +82,29 @@ type AggregatedMetricsWithInsight struct {
 	AggregatedMetrics []AggregatedMetric `json:"aggregated_metrics"`
 }
 
+type Evidence struct {
+	Section  Section `json:"section"`
+	Name     string  `json:"name,omitempty"`
+	Metric   string  `json:"metric"`
+	Value    string  `json:"value"`
+	Verified bool    `json:"verified"`
+}
+
+type Recommendation struct {
+	Rank           int        `json:"rank"`
+	Action         string     `json:"action"`
+	ExpectedImpact string     `json:"expected_impact"`
+	Confidence     string     `json:"confidence"`
+	Evidence       []Evidence `json:"evidence"`
+}
+
+type ExecutiveSummary struct {
+	Summary         string           `json:"summary"`
+	Recommendations []Recommendation `json:"recommendations"`
+}
+
 type UserMetricsWithInsights struct {
+	ExecutiveSummary                       ExecutiveSummary             `json:"executive_summary"`
 	OverallMetrics                         OverallMetricsWithInsight    `json:"overall_metrics"`
 	Top5CountriesWithHighestEngagementRate AggregatedMetricsWithInsight `json:"top_5_countries_with_highest_engagement_rate"`
 	Top5CountriesWithLowestEngagementRate  AggregatedMetricsWithInsight `json:"top_5_countries_with_lowest_engagement_rate"`
@@ -81,18 +114,131 @@ type UserMetricsWithInsights struct {
 	AverageSessionDurationsByDevices       AggregatedMetricsWithInsight `json:"average_session_durations_by_devices"`
 }
 
+type ToolCallLog struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+	Result    string `json:"result"`
+}
+
 type EmailData struct {
 	RecipientName string
 	UserMetricsWithInsights
+	ToolCalls  []ToolCallLog
+	Sections   []Section
+	Charts     map[Section]template.URL // chart image URLs, cid: in emails and data: in HTML files
+	Standalone bool                     // rendered as a document rather than a letter, without greeting and sign-off
+	Digest     *Digest                  // set when several data files are rendered together
+}
+
+// PropertyMetrics is the overall metrics of one data file, named after the file, for cross-property comparisons.
+type PropertyMetrics struct {
+	Name string
+	OverallMetrics
+}
+
+// EngagementRate returns the overall engagement rate as a percentage; it is stored as a fraction.
+func (p PropertyMetrics) EngagementRate() float64 {
+	return p.OverallEngagementRate * 100
+}
+
+// Digest is the data of a digest: a cross-property summary followed by the report of every data file.
+type Digest struct {
+	Summary string
+	Reports []DigestReport
+}
+
+// DigestReport is the report of one data file in a digest. Anchor is the HTML id of the report's heading.
+type DigestReport struct {
+	Property PropertyMetrics
+	Anchor   string
+	EmailData
+}
+
+// Chart returns the URL of the section's chart image, or an empty URL if the section has no chart.
+func (e EmailData) Chart(section Section) template.URL {
+	return e.Charts[section]
+}
+
+// Includes reports whether the given section should be rendered. All sections are rendered when none are selected.
+func (e EmailData) Includes(section Section) bool {
+	return IncludesSection(e.Sections, section)
+}
+
+// IncludesSection reports whether section is one of the selected sections, or whether none are selected.
+func IncludesSection(selected []Section, section Section) bool {
+	if len(selected) == 0 {
+		return true
+	}
+	for _, s := range selected {
+		if s == section {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValidSection reports whether section is a known report section.
+func IsValidSection(section Section) bool {
+	for _, valid := range SECTIONS {
+		if section == valid {
+			return true
+		}
+	}
+	return false
 }
 
 type EnvVariables struct {
-	FileDirectory string
-	ApiKey        string
-	EmailFrom     string
-	EmailPass     string
-	EmailTo       string
-	RecipientName string
-	SmtpHost      string
-	SmtpPort      string
+	FileDirectory     string
+	FileInclude       string    // comma-separated glob patterns of the data files
+	FileExclude       string    // comma-separated glob patterns of the files and directories to leave out
+	FileExtensions    string    // comma-separated extensions of the data files, or * for every file
+	FileMaxDepth      int       // levels of directories to walk, unlimited when 0
+	FileSymlinks      string    // symlink policy: skip, files or follow
+	FileHidden        bool      // include hidden files and directories
+	FileModifiedSince time.Time // leave out files last modified before, when set
+	S3Endpoint        string    // S3-compatible endpoint such as MinIO, AWS S3 when empty
+	S3Region          string
+	S3AccessKeyID     string
+	S3SecretAccessKey string
+	HTTPHeaders       map[string]string // headers of the requests for data files over HTTP, such as Authorization
+	ReportDirectory   string
+	ConfigFile        string
+	ApiKey            string
+	InsightEngine     string
+	LlmModel          string
+	LlmURL            string
+	MinDataPoints     int
+	EmailFrom         string
+	EmailPass         string
+	EmailTo           string
+	RecipientName     string
+	RecipientsFile    string
+	SmtpHost          string
+	SmtpPort          string
+	SmtpSecurity      string
+	SmtpAuth          string
+	SmtpCAFile        string
+	ChannelsFile      string
+	SlackWebhookURL   string
+	TeamsWebhookURL   string
+	WebhookURL        string
+	ChartFormat       string
+	PdfAttachment     bool
+	PdfDirectory      string
+	OutputFormats     string
+	OutputPath        string
+	TemplateDirectory string
+	ThemeFile         string
+	Digest            bool
+	OutboxDirectory   string
+	OutboxMaxAttempts int
+	OutboxBackoff     time.Duration
+	LedgerFile        string
+	Concurrency       int  // number of files processed at a time
+	LlmRequestsPerMin int  // requests per minute to the LLM provider, unlimited when 0
+	Force             bool // process files even if they have not changed since they were processed
+	ContinueOnError   bool // process every file even if one fails
+	OperatorEmail     string
+	DryRun            bool
+	DryRunDirectory   string
 }
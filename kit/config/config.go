@@ -0,0 +1,372 @@
+package config
+
+import (
+	"bytes"
+	"data-insights/kit/ai"
+	"data-insights/kit/chart"
+	"data-insights/kit/email"
+	"data-insights/kit/file"
+	"data-insights/kit/notify"
+	"data-insights/kit/output"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// IsConfigFile reports whether the path is a YAML or TOML config file rather than a file of KEY=value lines.
+func IsConfigFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ExtensionYAML, ExtensionYML, ExtensionTOML:
+		return true
+	}
+	return false
+}
+
+// Load reads a YAML or TOML config file, chosen by its extension. Unknown keys are rejected so that typos do not
+// go unnoticed. The config is not validated.
+func Load(path string) (Config, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to read config file: %v", err)
+	}
+
+	var config Config
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ExtensionYAML, ExtensionYML:
+		decoder := yaml.NewDecoder(bytes.NewReader(content))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&config); err != nil && !errors.Is(err, io.EOF) {
+			return Config{}, fmt.Errorf("failed to parse config file %s: %v", path, err)
+		}
+	case ExtensionTOML:
+		metadata, err := toml.Decode(string(content), &config)
+		if err != nil {
+			return Config{}, fmt.Errorf("failed to parse config file %s: %v", path, err)
+		}
+		if keys := unknownTOMLKeys(metadata); len(keys) > 0 {
+			return Config{}, fmt.Errorf("failed to parse config file %s: unknown keys %s", path, strings.Join(keys, ", "))
+		}
+	default:
+		return Config{}, fmt.Errorf("unsupported config file format: %s", path)
+	}
+	return config, nil
+}
+
+// Validate checks every setting and returns all problems at once, one per line and each prefixed with the path of
+// the setting. Secret references must resolve, so their environment variables and files must exist.
+func (c Config) Validate() error {
+	var problems []error
+	problem := func(field, format string, args ...interface{}) {
+		problems = append(problems, fmt.Errorf("%s: %s", field, fmt.Sprintf(format, args...)))
+	}
+
+	if c.Data.Concurrency < 0 {
+		problem("data.concurrency", "must be a positive number, got %d", c.Data.Concurrency)
+	}
+	filter := c.Data.Filter
+	if _, err := file.ParsePatterns(strings.Join(filter.Include, ",")); err != nil {
+		problem("data.filter.include", "%v", err)
+	}
+	if _, err := file.ParsePatterns(strings.Join(filter.Exclude, ",")); err != nil {
+		problem("data.filter.exclude", "%v", err)
+	}
+	if filter.MaxDepth < 0 {
+		problem("data.filter.max_depth", "must be a positive number, got %d", filter.MaxDepth)
+	}
+	if _, err := file.ParseSymlinkPolicy(filter.Symlinks); err != nil {
+		problem("data.filter.symlinks", "%v", err)
+	}
+	if _, err := file.ParseModifiedSince(filter.ModifiedSince, time.Now()); err != nil {
+		problem("data.filter.modified_since", "%v", err)
+	}
+	if c.Data.S3.Endpoint != "" {
+		if err := validateURL(c.Data.S3.Endpoint); err != nil {
+			problem("data.s3.endpoint", "%v", err)
+		}
+	}
+	if _, err := c.Data.S3.AccessKeyID.Resolve(); err != nil {
+		problem("data.s3.access_key_id", "%v", err)
+	}
+	if _, err := c.Data.S3.SecretAccessKey.Resolve(); err != nil {
+		problem("data.s3.secret_access_key", "%v", err)
+	}
+	if c.Data.S3.AccessKeyID.IsSet() != c.Data.S3.SecretAccessKey.IsSet() {
+		problem("data.s3", "access_key_id and secret_access_key must be set together")
+	}
+	for _, name := range c.Data.HTTP.headerNames() {
+		if _, err := c.Data.HTTP.Headers[name].Resolve(); err != nil {
+			problem("data.http.headers."+name, "%v", err)
+		}
+	}
+	if c.Metrics.MinDataPoints < 0 {
+		problem("metrics.min_data_points", "must be a positive number, got %d", c.Metrics.MinDataPoints)
+	}
+
+	switch c.LLM.Provider {
+	case "", ProviderOpenAI:
+	default:
+		problem("llm.provider", "unknown provider %q, must be %s", c.LLM.Provider, ProviderOpenAI)
+	}
+	switch ai.Engine(c.LLM.Engine) {
+	case "", ai.EngineAuto, ai.EngineLLM, ai.EngineRules:
+	default:
+		problem("llm.engine", "unknown engine %q, must be auto, llm or rules", c.LLM.Engine)
+	}
+	if c.LLM.URL != "" {
+		if err := validateURL(c.LLM.URL); err != nil {
+			problem("llm.url", "%v", err)
+		}
+	}
+	if _, err := c.LLM.APIKey.Resolve(); err != nil {
+		problem("llm.api_key", "%v", err)
+	}
+	if c.LLM.RequestsPerMinute < 0 {
+		problem("llm.requests_per_minute", "must be a positive number, got %d", c.LLM.RequestsPerMinute)
+	}
+
+	if _, err := c.Email.Password.Resolve(); err != nil {
+		problem("email.password", "%v", err)
+	}
+
+	smtp := c.Email.SMTP
+	if smtp.Port != 0 && (smtp.Port < 1 || smtp.Port > 65535) {
+		problem("email.smtp.port", "must be between 1 and 65535, got %d", smtp.Port)
+	}
+	switch email.SMTPSecurity(smtp.Security) {
+	case "", email.SecurityImplicitTLS, email.SecurityStartTLS, email.SecurityStartTLSOptional, email.SecurityNone:
+	default:
+		problem("email.smtp.security", "unknown security mode %q, must be tls, starttls, starttls-optional or none", smtp.Security)
+	}
+	switch email.SMTPAuth(smtp.Auth) {
+	case "", email.AuthPlain, email.AuthLogin, email.AuthCRAMMD5, email.AuthXOAuth2, email.AuthNone:
+	default:
+		problem("email.smtp.auth", "unknown auth mechanism %q, must be plain, login, cram-md5, xoauth2 or none", smtp.Auth)
+	}
+	if smtp.CAFile != "" {
+		if _, err := os.Stat(smtp.CAFile); err != nil {
+			problem("email.smtp.ca_file", "%v", err)
+		}
+	}
+
+	outbox := c.Email.Outbox
+	if outbox.MaxAttempts < 0 {
+		problem("email.outbox.max_attempts", "must be a positive number, got %d", outbox.MaxAttempts)
+	}
+	if outbox.Backoff != "" {
+		if backoff, err := time.ParseDuration(outbox.Backoff); err != nil || backoff < 0 {
+			problem("email.outbox.backoff", "invalid duration %q, must be a duration such as 5s", outbox.Backoff)
+		}
+	}
+
+	if c.Recipients != nil {
+		if err := c.Recipients.Validate(); err != nil {
+			problem("recipients", "%v", err)
+		}
+	}
+
+	for i, channel := range c.Channels {
+		field := fmt.Sprintf("channels[%d]", i)
+		webhookURL, err := channel.URL.Resolve()
+		if err != nil {
+			problem(field+".url", "%v", err)
+			continue
+		}
+		channelConfig := notify.Channel{Type: channel.Type, URL: webhookURL, Files: channel.Files, Sections: channel.Sections}
+		if err := channelConfig.Validate(); err != nil {
+			problem(field, "%v", err)
+		}
+	}
+
+	if _, err := output.ParseFormats(strings.Join(c.Output.Formats, ",")); err != nil {
+		problem("output.formats", "%v", err)
+	}
+	switch chart.Format(c.Output.ChartFormat) {
+	case "", chart.FormatPNG, chart.FormatSVG, chart.FormatNone:
+	default:
+		problem("output.chart_format", "unknown chart format %q, must be png, svg or none", c.Output.ChartFormat)
+	}
+
+	return errors.Join(problems...)
+}
+
+// Env returns the settings as the environment variables they correspond to, with the secrets resolved. Settings
+// that are not configured are left out, so that their defaults apply.
+func (c Config) Env() (map[string]string, error) {
+	apiKey, err := c.LLM.APIKey.Resolve()
+	if err != nil {
+		return nil, fmt.Errorf("llm.api_key: %v", err)
+	}
+	password, err := c.Email.Password.Resolve()
+	if err != nil {
+		return nil, fmt.Errorf("email.password: %v", err)
+	}
+
+	s3AccessKeyID, err := c.Data.S3.AccessKeyID.Resolve()
+	if err != nil {
+		return nil, fmt.Errorf("data.s3.access_key_id: %v", err)
+	}
+	s3SecretAccessKey, err := c.Data.S3.SecretAccessKey.Resolve()
+	if err != nil {
+		return nil, fmt.Errorf("data.s3.secret_access_key: %v", err)
+	}
+	httpHeaders, err := c.Data.HTTP.env()
+	if err != nil {
+		return nil, err
+	}
+
+	env := map[string]string{
+		"FILE_DIR":                c.Data.Directory,
+		"S3_ENDPOINT":             c.Data.S3.Endpoint,
+		"S3_REGION":               c.Data.S3.Region,
+		"S3_ACCESS_KEY_ID":        s3AccessKeyID,
+		"S3_SECRET_ACCESS_KEY":    s3SecretAccessKey,
+		"HTTP_HEADERS":            httpHeaders,
+		"REPORT_DIR":              c.Data.Reports,
+		"LEDGER_FILE":             c.Data.Ledger,
+		"INSIGHT_ENGINE":          c.LLM.Engine,
+		"LLM_MODEL":               c.LLM.Model,
+		"LLM_URL":                 c.LLM.URL,
+		"OPENAI_API_KEY":          apiKey,
+		"EMAIL_FROM":              c.Email.From,
+		"EMAIL_FROM_PASS":         password,
+		"EMAIL_TO":                c.Email.To,
+		"RECIPIENT_NAME":          c.Email.RecipientName,
+		"OPERATOR_EMAIL":          c.Email.Operator,
+		"SMTP_HOST":               c.Email.SMTP.Host,
+		"SMTP_SECURITY":           c.Email.SMTP.Security,
+		"SMTP_AUTH":               c.Email.SMTP.Auth,
+		"SMTP_CA_FILE":            c.Email.SMTP.CAFile,
+		"OUTBOX_DIR":              c.Email.Outbox.Directory,
+		"OUTBOX_BACKOFF":          c.Email.Outbox.Backoff,
+		"OUTPUT_FORMATS":          strings.Join(c.Output.Formats, ","),
+		"OUTPUT_PATH":             c.Output.Path,
+		"PDF_DIR":                 c.Output.PdfDir,
+		"CHART_FORMAT":            c.Output.ChartFormat,
+		"TEMPLATE_DIR":            c.Output.Templates,
+		"THEME_FILE":              c.Output.Theme,
+		"SMTP_PORT":               formatInt(c.Email.SMTP.Port),
+		"MIN_DATA_POINTS":         formatInt(c.Metrics.MinDataPoints),
+		"OUTBOX_MAX_ATTEMPTS":     formatInt(c.Email.Outbox.MaxAttempts),
+		"CONCURRENCY":             formatInt(c.Data.Concurrency),
+		"FILE_INCLUDE":            strings.Join(c.Data.Filter.Include, ","),
+		"FILE_EXCLUDE":            strings.Join(c.Data.Filter.Exclude, ","),
+		"FILE_EXTENSIONS":         strings.Join(c.Data.Filter.Extensions, ","),
+		"FILE_MAX_DEPTH":          formatInt(c.Data.Filter.MaxDepth),
+		"FILE_SYMLINKS":           c.Data.Filter.Symlinks,
+		"FILE_MODIFIED_SINCE":     c.Data.Filter.ModifiedSince,
+		"LLM_REQUESTS_PER_MINUTE": formatInt(c.LLM.RequestsPerMinute),
+	}
+	if c.Email.PdfAttachment {
+		env["PDF_ATTACHMENT"] = "true"
+	}
+	if c.Email.Digest {
+		env["DIGEST"] = "true"
+	}
+	if c.Data.ContinueOnError {
+		env["CONTINUE_ON_ERROR"] = "true"
+	}
+	if c.Data.Filter.Hidden {
+		env["FILE_HIDDEN"] = "true"
+	}
+	for key, value := range env {
+		if value == "" {
+			delete(env, key)
+		}
+	}
+	return env, nil
+}
+
+// env returns the headers with their values resolved as the JSON object of HTTP_HEADERS, or an empty string if
+// there are none.
+func (h HTTP) env() (string, error) {
+	if len(h.Headers) == 0 {
+		return "", nil
+	}
+	headers := make(map[string]string, len(h.Headers))
+	for _, name := range h.headerNames() {
+		value, err := h.Headers[name].Resolve()
+		if err != nil {
+			return "", fmt.Errorf("data.http.headers.%s: %v", name, err)
+		}
+		headers[name] = value
+	}
+	content, err := json.Marshal(headers)
+	if err != nil {
+		return "", fmt.Errorf("data.http.headers: %v", err)
+	}
+	return string(content), nil
+}
+
+// headerNames returns the names of the headers in order, so that problems are reported in a stable order.
+func (h HTTP) headerNames() []string {
+	names := make([]string, 0, len(h.Headers))
+	for name := range h.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NotifyChannels returns the delivery channels with their URLs resolved.
+func (c Config) NotifyChannels() (notify.ChannelsConfig, error) {
+	channels := notify.ChannelsConfig{Channels: make([]notify.Channel, 0, len(c.Channels))}
+	for i, channel := range c.Channels {
+		webhookURL, err := channel.URL.Resolve()
+		if err != nil {
+			return notify.ChannelsConfig{}, fmt.Errorf("channels[%d].url: %v", i, err)
+		}
+		channels.Channels = append(channels.Channels, notify.Channel{
+			Type:     channel.Type,
+			URL:      webhookURL,
+			Files:    channel.Files,
+			Sections: channel.Sections,
+		})
+	}
+	return channels, channels.Validate()
+}
+
+// unknownTOMLKeys returns the keys that were not decoded into the config. The fields of secret references are
+// decoded by Secret.UnmarshalTOML, which the metadata does not know about, so they are left out.
+func unknownTOMLKeys(metadata toml.MetaData) []string {
+	var keys []string
+	for _, key := range metadata.Undecoded() {
+		if len(key) >= 2 && (secretKeys[key[len(key)-2]] || isHeaderKey(key[:len(key)-1])) && (key[len(key)-1] == "env" || key[len(key)-1] == "file") {
+			continue
+		}
+		keys = append(keys, key.String())
+	}
+	return keys
+}
+
+// isHeaderKey reports whether the key is a header of data.http.headers, whose values are secret references.
+func isHeaderKey(key toml.Key) bool {
+	return len(key) == 4 && key[0] == "data" && key[1] == "http" && key[2] == "headers"
+}
+
+func formatInt(value int) string {
+	if value == 0 {
+		return ""
+	}
+	return strconv.Itoa(value)
+}
+
+func validateURL(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid url: %v", err)
+	}
+	if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("url %q must be an absolute http or https URL", rawURL)
+	}
+	return nil
+}
@@ -0,0 +1,18 @@
+package config
+
+const (
+	ProviderOpenAI = "openai"
+
+	// inlineSecretMessage explains how secrets are configured when one is found inline.
+	inlineSecretMessage = "secrets must be referenced as {env: NAME} or {file: PATH}, not stored inline"
+)
+
+// Extensions of the config file formats. Any other config file is read as KEY=value lines.
+const (
+	ExtensionYAML = ".yaml"
+	ExtensionYML  = ".yml"
+	ExtensionTOML = ".toml"
+)
+
+// secretKeys are the names of the settings that hold secret references.
+var secretKeys = map[string]bool{"api_key": true, "password": true, "url": true, "access_key_id": true, "secret_access_key": true}
@@ -0,0 +1,119 @@
+package config
+
+import (
+	"data-insights/kit/common"
+	"data-insights/kit/email"
+	"data-insights/kit/notify"
+)
+
+// Config is the typed configuration file. Every setting is optional and maps to the environment variable of the
+// same setting, which overrides it.
+type Config struct {
+	Data       DataConfig              `yaml:"data" toml:"data"`
+	Metrics    MetricsConfig           `yaml:"metrics" toml:"metrics"`
+	LLM        LLMConfig               `yaml:"llm" toml:"llm"`
+	Email      EmailConfig             `yaml:"email" toml:"email"`
+	Recipients *email.RecipientsConfig `yaml:"recipients" toml:"recipients"`
+	Channels   []Channel               `yaml:"channels" toml:"channels"`
+	Output     OutputConfig            `yaml:"output" toml:"output"`
+}
+
+type DataConfig struct {
+	Directory       string `yaml:"directory" toml:"directory"`                 // FILE_DIR
+	Reports         string `yaml:"reports" toml:"reports"`                     // REPORT_DIR
+	Ledger          string `yaml:"ledger" toml:"ledger"`                       // LEDGER_FILE
+	Concurrency     int    `yaml:"concurrency" toml:"concurrency"`             // CONCURRENCY
+	ContinueOnError bool   `yaml:"continue_on_error" toml:"continue_on_error"` // CONTINUE_ON_ERROR
+	Filter          Filter `yaml:"filter" toml:"filter"`
+	S3              S3     `yaml:"s3" toml:"s3"`
+	HTTP            HTTP   `yaml:"http" toml:"http"`
+}
+
+// Filter selects the data files in the data directory.
+type Filter struct {
+	Include       []string `yaml:"include" toml:"include"`               // FILE_INCLUDE
+	Exclude       []string `yaml:"exclude" toml:"exclude"`               // FILE_EXCLUDE
+	Extensions    []string `yaml:"extensions" toml:"extensions"`         // FILE_EXTENSIONS
+	MaxDepth      int      `yaml:"max_depth" toml:"max_depth"`           // FILE_MAX_DEPTH
+	Symlinks      string   `yaml:"symlinks" toml:"symlinks"`             // FILE_SYMLINKS
+	Hidden        bool     `yaml:"hidden" toml:"hidden"`                 // FILE_HIDDEN
+	ModifiedSince string   `yaml:"modified_since" toml:"modified_since"` // FILE_MODIFIED_SINCE, e.g. "24h"
+}
+
+// S3 is the S3-compatible object storage that s3:// data directories are read from.
+type S3 struct {
+	Endpoint        string `yaml:"endpoint" toml:"endpoint"`                   // S3_ENDPOINT, e.g. a MinIO server
+	Region          string `yaml:"region" toml:"region"`                       // S3_REGION
+	AccessKeyID     Secret `yaml:"access_key_id" toml:"access_key_id"`         // S3_ACCESS_KEY_ID
+	SecretAccessKey Secret `yaml:"secret_access_key" toml:"secret_access_key"` // S3_SECRET_ACCESS_KEY
+}
+
+// HTTP holds the headers of the requests for data files over HTTP. Header values usually carry credentials, so
+// they are secrets.
+type HTTP struct {
+	Headers map[string]Secret `yaml:"headers" toml:"headers"` // HTTP_HEADERS
+}
+
+type MetricsConfig struct {
+	MinDataPoints int `yaml:"min_data_points" toml:"min_data_points"` // MIN_DATA_POINTS
+}
+
+type LLMConfig struct {
+	Provider          string `yaml:"provider" toml:"provider"`                       // only openai is supported
+	Engine            string `yaml:"engine" toml:"engine"`                           // INSIGHT_ENGINE
+	Model             string `yaml:"model" toml:"model"`                             // LLM_MODEL
+	URL               string `yaml:"url" toml:"url"`                                 // LLM_URL
+	APIKey            Secret `yaml:"api_key" toml:"api_key"`                         // OPENAI_API_KEY
+	RequestsPerMinute int    `yaml:"requests_per_minute" toml:"requests_per_minute"` // LLM_REQUESTS_PER_MINUTE
+}
+
+type EmailConfig struct {
+	From          string       `yaml:"from" toml:"from"`                     // EMAIL_FROM
+	Password      Secret       `yaml:"password" toml:"password"`             // EMAIL_FROM_PASS
+	To            string       `yaml:"to" toml:"to"`                         // EMAIL_TO
+	RecipientName string       `yaml:"recipient_name" toml:"recipient_name"` // RECIPIENT_NAME
+	Operator      string       `yaml:"operator" toml:"operator"`             // OPERATOR_EMAIL, receives the failed files
+	SMTP          SMTPConfig   `yaml:"smtp" toml:"smtp"`
+	Outbox        OutboxConfig `yaml:"outbox" toml:"outbox"`
+	PdfAttachment bool         `yaml:"pdf_attachment" toml:"pdf_attachment"` // PDF_ATTACHMENT
+	Digest        bool         `yaml:"digest" toml:"digest"`                 // DIGEST
+}
+
+type SMTPConfig struct {
+	Host     string `yaml:"host" toml:"host"`         // SMTP_HOST
+	Port     int    `yaml:"port" toml:"port"`         // SMTP_PORT
+	Security string `yaml:"security" toml:"security"` // SMTP_SECURITY
+	Auth     string `yaml:"auth" toml:"auth"`         // SMTP_AUTH
+	CAFile   string `yaml:"ca_file" toml:"ca_file"`   // SMTP_CA_FILE
+}
+
+type OutboxConfig struct {
+	Directory   string `yaml:"directory" toml:"directory"`       // OUTBOX_DIR
+	MaxAttempts int    `yaml:"max_attempts" toml:"max_attempts"` // OUTBOX_MAX_ATTEMPTS
+	Backoff     string `yaml:"backoff" toml:"backoff"`           // OUTBOX_BACKOFF, e.g. "5s"
+}
+
+// Channel is a delivery channel. Webhook URLs carry credentials, so they are secrets.
+type Channel struct {
+	Type     notify.ChannelType `yaml:"type" toml:"type"`
+	URL      Secret             `yaml:"url" toml:"url"`
+	Files    []string           `yaml:"files" toml:"files"`
+	Sections []common.Section   `yaml:"sections" toml:"sections"`
+}
+
+type OutputConfig struct {
+	Formats     []string `yaml:"formats" toml:"formats"`           // OUTPUT_FORMATS
+	Path        string   `yaml:"path" toml:"path"`                 // OUTPUT_PATH
+	PdfDir      string   `yaml:"pdf_dir" toml:"pdf_dir"`           // PDF_DIR
+	ChartFormat string   `yaml:"chart_format" toml:"chart_format"` // CHART_FORMAT
+	Templates   string   `yaml:"templates" toml:"templates"`       // TEMPLATE_DIR
+	Theme       string   `yaml:"theme" toml:"theme"`               // THEME_FILE
+}
+
+// Secret references a secret held in an environment variable or a file, so that it is never stored in the
+// config file itself.
+type Secret struct {
+	Env    string `yaml:"env" toml:"env"`
+	File   string `yaml:"file" toml:"file"`
+	inline bool   // set when the config file holds the secret itself, which Validate reports
+}
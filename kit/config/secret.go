@@ -0,0 +1,88 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+	"strings"
+)
+
+// UnmarshalYAML accepts a secret reference. A plain value is accepted too, but marked as inline so that Validate
+// can report it together with every other problem. Decoding the reference loses the decoder's KnownFields, so
+// unknown fields are rejected here.
+func (s *Secret) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*s = Secret{inline: node.Value != ""}
+		return nil
+	}
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i < len(node.Content); i += 2 {
+			if key := node.Content[i].Value; key != "env" && key != "file" {
+				return fmt.Errorf("line %d: unknown secret field %q", node.Content[i].Line, key)
+			}
+		}
+	}
+	type reference Secret
+	return node.Decode((*reference)(s))
+}
+
+// UnmarshalTOML accepts a secret reference table, or marks a plain value as inline like UnmarshalYAML.
+func (s *Secret) UnmarshalTOML(value interface{}) error {
+	switch v := value.(type) {
+	case string:
+		*s = Secret{inline: v != ""}
+	case map[string]interface{}:
+		*s = Secret{}
+		for key, entry := range v {
+			text, ok := entry.(string)
+			if !ok {
+				return fmt.Errorf("secret %s must be a string", key)
+			}
+			switch key {
+			case "env":
+				s.Env = text
+			case "file":
+				s.File = text
+			default:
+				return fmt.Errorf("unknown secret field %q", key)
+			}
+		}
+	default:
+		*s = Secret{inline: true}
+	}
+	return nil
+}
+
+// IsSet reports whether the secret is configured, by reference or inline.
+func (s Secret) IsSet() bool {
+	return s.Env != "" || s.File != "" || s.inline
+}
+
+// Resolve returns the secret's value from its environment variable or file, without surrounding whitespace.
+func (s Secret) Resolve() (string, error) {
+	switch {
+	case s.inline:
+		return "", errors.New(inlineSecretMessage)
+	case s.Env != "" && s.File != "":
+		return "", errors.New("a secret references either env or file, not both")
+	case s.Env != "":
+		value, ok := os.LookupEnv(s.Env)
+		if !ok || value == "" {
+			return "", fmt.Errorf("environment variable %s is not set", s.Env)
+		}
+		return value, nil
+	case s.File != "":
+		content, err := os.ReadFile(s.File)
+		if err != nil {
+			return "", fmt.Errorf("failed to read secret file: %v", err)
+		}
+		value := strings.TrimSpace(string(content))
+		if value == "" {
+			return "", fmt.Errorf("secret file %s is empty", s.File)
+		}
+		return value, nil
+	default:
+		return "", nil
+	}
+}
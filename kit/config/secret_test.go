@@ -0,0 +1,53 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	return path
+}
+
+func TestLoadReadsSecretReferences(t *testing.T) {
+	tests := map[string]string{
+		"config.yaml": "llm:\n  api_key: {env: OPENAI_API_KEY}\n",
+		"config.toml": "[llm]\napi_key = { env = \"OPENAI_API_KEY\" }\n",
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			config, err := Load(writeConfig(t, name, content))
+			if err != nil {
+				t.Fatalf("failed to load config: %v", err)
+			}
+			if config.LLM.APIKey.Env != "OPENAI_API_KEY" {
+				t.Errorf("got env %q, want OPENAI_API_KEY", config.LLM.APIKey.Env)
+			}
+		})
+	}
+}
+
+func TestLoadRejectsUnknownSecretFields(t *testing.T) {
+	tests := map[string]string{
+		"config.yaml": "llm:\n  api_key: {evn: OPENAI_API_KEY}\n",
+		"config.toml": "[llm]\napi_key = { evn = \"OPENAI_API_KEY\" }\n",
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Load(writeConfig(t, name, content))
+			if err == nil {
+				t.Fatal("loaded a secret with an unknown field")
+			}
+			if !strings.Contains(err.Error(), `"evn"`) {
+				t.Errorf("error %q does not name the unknown field", err)
+			}
+		})
+	}
+}
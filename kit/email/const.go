@@ -1,7 +1,47 @@
 package email
 
+import "time"
+
+const (
+	TemplateDir        = "templates"
+	PartialsDir        = "partials"
+	LayoutTemplate     = "layout"  // base layout, executed to render a report
+	ContentBlock       = "content" // report body, filled in by the page template
+	SubjectName        = "Website Insights Report"
+	DigestSubjectName  = "Website Insights Digest"
+	FailureSubjectName = "Website Insights Run Failures"
+	DryRunDir          = "dry-run" // default directory for messages written instead of sent
+)
+
+const (
+	OutboxDir           = "outbox"
+	OutboxPendingDir    = "pending" // messages waiting to be sent
+	OutboxDeadLetterDir = "dead"    // messages that failed every attempt
+	DefaultMaxAttempts  = 3
+	DefaultRetryBackoff = 5 * time.Second // doubled after every failed attempt
+)
+
+const (
+	StatusGood    = "good"
+	StatusWarning = "warning"
+	StatusBad     = "bad"
+)
+
+type SMTPSecurity string
+
+const (
+	SecurityImplicitTLS      SMTPSecurity = "tls"               // TLS from the first byte, usually port 465
+	SecurityStartTLS         SMTPSecurity = "starttls"          // STARTTLS required
+	SecurityStartTLSOptional SMTPSecurity = "starttls-optional" // STARTTLS when the server offers it
+	SecurityNone             SMTPSecurity = "none"              // plaintext, for local relays
+)
+
+type SMTPAuth string
+
 const (
-	TemplateDir  = "templates"
-	TemplateFile = "email_template.html"
-	SubjectName  = "Website Insights Report"
+	AuthPlain   SMTPAuth = "plain"
+	AuthLogin   SMTPAuth = "login"
+	AuthCRAMMD5 SMTPAuth = "cram-md5"
+	AuthXOAuth2 SMTPAuth = "xoauth2" // the password is used as the OAuth 2.0 access token
+	AuthNone    SMTPAuth = "none"
 )
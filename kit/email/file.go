@@ -0,0 +1,60 @@
+package email
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+)
+
+var unsafeFilenameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// FileEmailService writes messages to disk instead of sending them, for dry runs. Every message is written as
+// the exact MIME message that would be sent (.eml) and as its HTML body (.html).
+type FileEmailService struct {
+	Dir         string
+	SenderEmail string
+
+	mu    sync.Mutex
+	count int
+}
+
+func NewFileEmailService(dir, senderEmail string) *FileEmailService {
+	return &FileEmailService{Dir: dir, SenderEmail: senderEmail}
+}
+
+// SendEmail builds the message and writes it to the service's directory, named after the time, a sequence number
+// and the first recipient.
+func (s *FileEmailService) SendEmail(message Message) error {
+	msg, err := BuildMessage(s.SenderEmail, message)
+	if err != nil {
+		return fmt.Errorf("failed to build message: %v", err)
+	}
+	if err := os.MkdirAll(s.Dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create dry run directory: %v", err)
+	}
+
+	s.mu.Lock()
+	s.count++
+	count := s.count
+	s.mu.Unlock()
+
+	recipient := "undisclosed-recipients"
+	if len(message.To) > 0 {
+		recipient = message.To[0]
+	}
+	base := filepath.Join(s.Dir, fmt.Sprintf("%s-%03d-%s", time.Now().Format("20060102-150405"), count,
+		unsafeFilenameChars.ReplaceAllString(recipient, "_")))
+
+	if err := os.WriteFile(base+".eml", msg, 0o644); err != nil {
+		return fmt.Errorf("failed to write message: %v", err)
+	}
+	if err := os.WriteFile(base+".html", []byte(message.Body), 0o644); err != nil {
+		return fmt.Errorf("failed to write HTML body: %v", err)
+	}
+	log.Printf("Dry run: email to %s written to %s.eml", recipient, base)
+	return nil
+}
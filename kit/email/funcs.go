@@ -0,0 +1,110 @@
+package email
+
+import (
+	"data-insights/kit/metrics"
+	"fmt"
+	"html/template"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// templateFuncs returns the functions available to the templates. The formatting functions accept numbers as
+// well as formatted values such as "84.37 seconds", and return values that are not numbers unchanged.
+func templateFuncs(theme Theme) template.FuncMap {
+	return template.FuncMap{
+		"number":       formatNumber,
+		"percent":      formatPercent,
+		"duration":     formatDuration,
+		"delta":        formatDelta,
+		"status":       theme.Status,
+		"statusColour": theme.StatusColour,
+		"theme":        func() Theme { return theme },
+	}
+}
+
+// formatNumber formats a number with thousands separators, and two decimals unless it is whole, e.g. "12,345".
+func formatNumber(value interface{}) string {
+	number, ok := toNumber(value)
+	if !ok {
+		return fmt.Sprint(value)
+	}
+	text := strconv.FormatFloat(math.Abs(number), 'f', 2, 64)
+	if number == math.Trunc(number) {
+		text = strconv.FormatFloat(math.Abs(number), 'f', 0, 64)
+	}
+	whole, decimals, _ := strings.Cut(text, ".")
+	for i := len(whole) - 3; i > 0; i -= 3 {
+		whole = whole[:i] + "," + whole[i:]
+	}
+	if decimals != "" {
+		whole += "." + decimals
+	}
+	if number < 0 {
+		whole = "-" + whole
+	}
+	return whole
+}
+
+// formatPercent formats a percentage value, e.g. "46.80%".
+func formatPercent(value interface{}) string {
+	number, ok := toNumber(value)
+	if !ok {
+		return fmt.Sprint(value)
+	}
+	return fmt.Sprintf("%.2f%%", number)
+}
+
+// formatDuration formats a number of seconds, e.g. "45s", "1m 24s" or "2h 5m".
+func formatDuration(value interface{}) string {
+	number, ok := toNumber(value)
+	if !ok {
+		return fmt.Sprint(value)
+	}
+	seconds := int(math.Round(number))
+	switch {
+	case seconds < 60:
+		return fmt.Sprintf("%ds", seconds)
+	case seconds < 3600:
+		return fmt.Sprintf("%dm %ds", seconds/60, seconds%60)
+	default:
+		return fmt.Sprintf("%dh %dm", seconds/3600, seconds%3600/60)
+	}
+}
+
+// formatDelta formats the signed difference between value and reference, in points when either is a
+// percentage, e.g. "+17.28 pts".
+func formatDelta(value, reference interface{}) string {
+	number, ok := toNumber(value)
+	base, baseOk := toNumber(reference)
+	if !ok || !baseOk {
+		return ""
+	}
+	delta := fmt.Sprintf("%+.2f", number-base)
+	if isPercent(value) || isPercent(reference) {
+		delta += " pts"
+	}
+	return delta
+}
+
+func toNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case string:
+		return metrics.ParseNumber(v)
+	default:
+		return 0, false
+	}
+}
+
+func isPercent(value interface{}) bool {
+	text, ok := value.(string)
+	return ok && strings.Contains(text, "%")
+}
@@ -0,0 +1,201 @@
+package email
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+const base64LineLength = 76
+
+// BuildMessage renders the message as an RFC 5322 email with CRLF line endings. The body is a multipart/alternative
+// with a plain-text and an HTML part, where the HTML part is a multipart/related with the images when there are
+// inline images, wrapped in multipart/mixed when there are attachments.
+func BuildMessage(from string, message Message) ([]byte, error) {
+	var buf bytes.Buffer
+
+	headers := []struct{ name, value string }{
+		{"From", formatAddressList([]string{from})},
+		{"To", formatAddressList(message.To)},
+		{"Cc", formatAddressList(message.Cc)},
+		{"Subject", mime.QEncoding.Encode("utf-8", message.Subject)},
+		{"Date", time.Now().Format(time.RFC1123Z)},
+		{"Message-ID", newMessageID(from)},
+		{"MIME-Version", "1.0"},
+	}
+	for _, header := range headers {
+		if header.value != "" {
+			fmt.Fprintf(&buf, "%s: %s\r\n", header.name, header.value)
+		}
+	}
+
+	contentType, body, err := buildBody(message)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(&buf, "Content-Type: %s\r\n\r\n", contentType)
+	buf.Write(body)
+	return buf.Bytes(), nil
+}
+
+// buildBody returns the content type and the encoded body of the message, nesting the multipart levels as needed.
+func buildBody(message Message) (string, []byte, error) {
+	text := message.Text
+	if text == "" {
+		text = HTMLToText(message.Body)
+	}
+
+	contentType, body, err := buildMultipart("alternative", nil, func(w *multipart.Writer) error {
+		if err := writeQuotedPrintablePart(w, "text/plain; charset=UTF-8", text); err != nil {
+			return err
+		}
+		if len(message.Inline) == 0 {
+			return writeQuotedPrintablePart(w, "text/html; charset=UTF-8", message.Body)
+		}
+		// The HTML part and the images it references by cid: form one multipart/related part (RFC 2387)
+		relatedType, related, err := buildMultipart("related", map[string]string{"type": "text/html"}, func(w *multipart.Writer) error {
+			if err := writeQuotedPrintablePart(w, "text/html; charset=UTF-8", message.Body); err != nil {
+				return err
+			}
+			for _, attachment := range message.Inline {
+				if err := writeAttachmentPart(w, attachment, "inline"); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		return writeRawPart(w, relatedType, related)
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	if len(message.Attachments) > 0 {
+		contentType, body, err = buildMultipart("mixed", nil, func(w *multipart.Writer) error {
+			if err := writeRawPart(w, contentType, body); err != nil {
+				return err
+			}
+			for _, attachment := range message.Attachments {
+				if err := writeAttachmentPart(w, attachment, "attachment"); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return "", nil, err
+		}
+	}
+
+	return contentType, body, nil
+}
+
+// buildMultipart writes the parts as a multipart/<subtype> body and returns its content type with the boundary and
+// the given parameters.
+func buildMultipart(subtype string, params map[string]string, writeParts func(w *multipart.Writer) error) (string, []byte, error) {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	if err := writeParts(w); err != nil {
+		return "", nil, err
+	}
+	if err := w.Close(); err != nil {
+		return "", nil, fmt.Errorf("failed to close multipart/%s: %v", subtype, err)
+	}
+	mediaParams := map[string]string{"boundary": w.Boundary()}
+	for name, value := range params {
+		mediaParams[name] = value
+	}
+	return mime.FormatMediaType("multipart/"+subtype, mediaParams), buf.Bytes(), nil
+}
+
+func writeQuotedPrintablePart(w *multipart.Writer, contentType, content string) error {
+	part, err := w.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {contentType},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create %s part: %v", contentType, err)
+	}
+	qp := quotedprintable.NewWriter(part)
+	if _, err := qp.Write([]byte(strings.ReplaceAll(strings.ReplaceAll(content, "\r\n", "\n"), "\n", "\r\n"))); err != nil {
+		return fmt.Errorf("failed to write %s part: %v", contentType, err)
+	}
+	return qp.Close()
+}
+
+func writeRawPart(w *multipart.Writer, contentType string, content []byte) error {
+	part, err := w.CreatePart(textproto.MIMEHeader{"Content-Type": {contentType}})
+	if err != nil {
+		return fmt.Errorf("failed to create %s part: %v", contentType, err)
+	}
+	_, err = part.Write(content)
+	return err
+}
+
+func writeAttachmentPart(w *multipart.Writer, attachment Attachment, disposition string) error {
+	contentType := attachment.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	header := textproto.MIMEHeader{
+		"Content-Type":              {mime.FormatMediaType(contentType, map[string]string{"name": attachment.Filename})},
+		"Content-Transfer-Encoding": {"base64"},
+		"Content-Disposition":       {mime.FormatMediaType(disposition, map[string]string{"filename": attachment.Filename})},
+	}
+	if attachment.ContentID != "" {
+		header.Set("Content-ID", "<"+attachment.ContentID+">")
+	}
+	part, err := w.CreatePart(header)
+	if err != nil {
+		return fmt.Errorf("failed to create part for %s: %v", attachment.Filename, err)
+	}
+
+	encoded := base64.StdEncoding.EncodeToString(attachment.Data)
+	for len(encoded) > base64LineLength {
+		if _, err := fmt.Fprintf(part, "%s\r\n", encoded[:base64LineLength]); err != nil {
+			return err
+		}
+		encoded = encoded[base64LineLength:]
+	}
+	_, err = fmt.Fprintf(part, "%s\r\n", encoded)
+	return err
+}
+
+// formatAddressList formats addresses for a header, encoding display names where needed. Addresses that cannot
+// be parsed are used as they are.
+func formatAddressList(addresses []string) string {
+	formatted := make([]string, 0, len(addresses))
+	for _, address := range addresses {
+		if parsed, err := mail.ParseAddress(address); err == nil {
+			formatted = append(formatted, parsed.String())
+		} else {
+			formatted = append(formatted, address)
+		}
+	}
+	return strings.Join(formatted, ", ")
+}
+
+// newMessageID returns a unique Message-ID in the sender's domain.
+func newMessageID(from string) string {
+	domain := "localhost"
+	if parsed, err := mail.ParseAddress(from); err == nil {
+		if at := strings.LastIndex(parsed.Address, "@"); at >= 0 {
+			domain = parsed.Address[at+1:]
+		}
+	}
+	random := make([]byte, 12)
+	_, _ = rand.Read(random)
+	return fmt.Sprintf("<%d.%s@%s>", time.Now().UnixNano(), hex.EncodeToString(random), domain)
+}
@@ -0,0 +1,82 @@
+package email
+
+import (
+	"data-insights/kit/common"
+	"time"
+)
+
+type Message struct {
+	To          []string
+	Cc          []string
+	Bcc         []string
+	Subject     string
+	Body        string // HTML body
+	Text        string // Plain-text alternative, generated from Body when empty
+	Inline      []Attachment
+	Attachments []Attachment
+}
+
+// Attachment is a file sent with a message. Inline attachments are referenced from the HTML body as cid:ContentID.
+type Attachment struct {
+	Filename    string
+	ContentType string
+	ContentID   string
+	Data        []byte
+}
+
+// QueuedMessage is a rendered message persisted in the outbox until it is sent.
+type QueuedMessage struct {
+	ID        string    `json:"id"`
+	Message   Message   `json:"message"`
+	Attempts  int       `json:"attempts"`
+	LastError string    `json:"last_error,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type Recipient struct {
+	Name     string           `json:"name"`
+	Email    string           `json:"email"`
+	Cc       []string         `json:"cc,omitempty"`
+	Bcc      []string         `json:"bcc,omitempty"`
+	Sections []common.Section `json:"sections,omitempty"`
+	Files    []string         `json:"files,omitempty"`
+}
+
+type RecipientsConfig struct {
+	Recipients []Recipient         `json:"recipients"`
+	Groups     map[string][]string `json:"groups,omitempty"`
+	To         []string            `json:"to,omitempty"`
+}
+
+// Delivery is a single personalised message: one recipient with the addresses copied on it and the sections
+// they receive.
+type Delivery struct {
+	Name     string
+	To       string
+	Cc       []string
+	Bcc      []string
+	Sections []common.Section
+}
+
+// Theme is the branding of the rendered reports. Thresholds colour-code metric values by their JSON name.
+type Theme struct {
+	BrandName     string               `json:"brand_name,omitempty"`
+	LogoURL       string               `json:"logo_url,omitempty"`
+	PrimaryColour string               `json:"primary_colour,omitempty"`
+	TextColour    string               `json:"text_colour,omitempty"`
+	AccentColour  string               `json:"accent_colour,omitempty"`
+	BorderColour  string               `json:"border_colour,omitempty"`
+	GoodColour    string               `json:"good_colour,omitempty"`
+	WarningColour string               `json:"warning_colour,omitempty"`
+	BadColour     string               `json:"bad_colour,omitempty"`
+	Footer        string               `json:"footer,omitempty"`
+	Thresholds    map[string]Threshold `json:"thresholds,omitempty"`
+}
+
+// Threshold classifies values as good from Good onwards and bad from Bad onwards. Lower values are better when
+// Good is below Bad.
+type Threshold struct {
+	Good float64 `json:"good"`
+	Bad  float64 `json:"bad"`
+}
@@ -0,0 +1,231 @@
+package email
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Outbox persists every message to disk before it is sent, so that a failed delivery never loses the work that
+// produced it. Failed sends are retried with exponential backoff, and messages that fail every attempt are moved
+// to the dead-letter directory, from which they can be replayed.
+type Outbox struct {
+	Dir         string
+	Service     EmailService
+	MaxAttempts int
+	Backoff     time.Duration
+	sleep       func(time.Duration)
+}
+
+func NewOutbox(dir string, service EmailService, maxAttempts int, backoff time.Duration) *Outbox {
+	if maxAttempts < 1 {
+		maxAttempts = DefaultMaxAttempts
+	}
+	return &Outbox{Dir: dir, Service: service, MaxAttempts: maxAttempts, Backoff: backoff, sleep: time.Sleep}
+}
+
+// SendEmail queues the message and delivers it. It returns an error if the message could not be queued, or if it
+// failed every attempt and was moved to the dead-letter directory.
+func (o *Outbox) SendEmail(message Message) error {
+	queued, err := o.Enqueue(message)
+	if err != nil {
+		return err
+	}
+	return o.deliver(queued)
+}
+
+// Enqueue persists the message in the pending directory without sending it.
+func (o *Outbox) Enqueue(message Message) (QueuedMessage, error) {
+	id, err := newQueueID()
+	if err != nil {
+		return QueuedMessage{}, err
+	}
+	now := time.Now().UTC()
+	queued := QueuedMessage{ID: id, Message: message, CreatedAt: now, UpdatedAt: now}
+	if err := o.save(OutboxPendingDir, queued); err != nil {
+		return QueuedMessage{}, err
+	}
+	return queued, nil
+}
+
+// Flush delivers every pending message, oldest first, such as messages left behind by an interrupted run. Each
+// message gets a fresh set of attempts, so that one whose attempts ran out before it could be moved to the
+// dead-letter directory is still tried. It returns the number of messages sent and the errors of those that failed.
+func (o *Outbox) Flush() (int, error) {
+	pending, err := o.List(OutboxPendingDir)
+	if err != nil {
+		return 0, err
+	}
+	for i := range pending {
+		pending[i].Attempts = 0
+	}
+	return o.deliverAll(pending)
+}
+
+// Replay moves the dead-letter messages with the given IDs, or all of them when none are given, back to the
+// pending directory with a fresh set of attempts and delivers them.
+func (o *Outbox) Replay(ids []string) (int, error) {
+	dead, err := o.List(OutboxDeadLetterDir)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, id := range ids {
+		if !containsMessage(dead, id) {
+			return 0, fmt.Errorf("message %s is not in the dead-letter directory", id)
+		}
+	}
+
+	var replay []QueuedMessage
+	for _, queued := range dead {
+		if len(ids) > 0 && !containsID(ids, queued.ID) {
+			continue
+		}
+		queued.Attempts = 0
+		queued.LastError = ""
+		if err := o.move(queued, OutboxDeadLetterDir, OutboxPendingDir); err != nil {
+			return 0, err
+		}
+		replay = append(replay, queued)
+	}
+	return o.deliverAll(replay)
+}
+
+// List returns the messages in the given outbox subdirectory, oldest first.
+func (o *Outbox) List(subdir string) ([]QueuedMessage, error) {
+	paths, err := filepath.Glob(filepath.Join(o.Dir, subdir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list outbox: %v", err)
+	}
+
+	var messages []QueuedMessage
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read queued message: %v", err)
+		}
+		var queued QueuedMessage
+		if err := json.Unmarshal(content, &queued); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal queued message %s: %v", filepath.Base(path), err)
+		}
+		messages = append(messages, queued)
+	}
+	sort.Slice(messages, func(i, j int) bool { return messages[i].CreatedAt.Before(messages[j].CreatedAt) })
+	return messages, nil
+}
+
+func (o *Outbox) deliverAll(messages []QueuedMessage) (int, error) {
+	var sent int
+	var errs []error
+	for _, queued := range messages {
+		if err := o.deliver(queued); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		sent++
+	}
+	return sent, errors.Join(errs...)
+}
+
+// deliver sends a queued message, waiting Backoff, then twice as long, and so on between attempts. The message is
+// removed once sent, or moved to the dead-letter directory after MaxAttempts failed attempts.
+func (o *Outbox) deliver(queued QueuedMessage) error {
+	backoff := o.Backoff
+	for queued.Attempts < o.MaxAttempts {
+		err := o.Service.SendEmail(queued.Message)
+		if err == nil {
+			if err := os.Remove(o.path(OutboxPendingDir, queued.ID)); err != nil {
+				log.Printf("Failed to remove sent message %s from the outbox: %v", queued.ID, err)
+			}
+			return nil
+		}
+
+		queued.Attempts++
+		queued.LastError = err.Error()
+		queued.UpdatedAt = time.Now().UTC()
+		if saveErr := o.save(OutboxPendingDir, queued); saveErr != nil {
+			log.Printf("Failed to update queued message %s: %v", queued.ID, saveErr)
+		}
+		if queued.Attempts >= o.MaxAttempts {
+			break
+		}
+		log.Printf("Sending message %s to %s failed (attempt %d of %d), retrying in %s: %v", queued.ID,
+			strings.Join(queued.Message.To, ", "), queued.Attempts, o.MaxAttempts, backoff, err)
+		o.sleep(backoff)
+		backoff *= 2
+	}
+
+	if err := o.move(queued, OutboxPendingDir, OutboxDeadLetterDir); err != nil {
+		return err
+	}
+	return fmt.Errorf("message %s to %s failed %d attempts and was moved to the dead-letter directory: %s",
+		queued.ID, strings.Join(queued.Message.To, ", "), queued.Attempts, queued.LastError)
+}
+
+// save writes the message to a temporary file first, so that an interrupted write never leaves a corrupt message.
+func (o *Outbox) save(subdir string, queued QueuedMessage) error {
+	if err := os.MkdirAll(filepath.Join(o.Dir, subdir), 0o700); err != nil {
+		return fmt.Errorf("failed to create outbox directory: %v", err)
+	}
+	content, err := json.Marshal(queued)
+	if err != nil {
+		return fmt.Errorf("failed to marshal queued message: %v", err)
+	}
+	path := o.path(subdir, queued.ID)
+	if err := os.WriteFile(path+".tmp", content, 0o600); err != nil {
+		return fmt.Errorf("failed to write queued message: %v", err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return fmt.Errorf("failed to write queued message: %v", err)
+	}
+	return nil
+}
+
+func (o *Outbox) move(queued QueuedMessage, from, to string) error {
+	if err := o.save(to, queued); err != nil {
+		return err
+	}
+	if err := os.Remove(o.path(from, queued.ID)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove queued message: %v", err)
+	}
+	return nil
+}
+
+func (o *Outbox) path(subdir, id string) string {
+	return filepath.Join(o.Dir, subdir, id+".json")
+}
+
+// newQueueID returns a sortable, unique message ID made of the current time and random bytes.
+func newQueueID() (string, error) {
+	random := make([]byte, 4)
+	if _, err := rand.Read(random); err != nil {
+		return "", fmt.Errorf("failed to generate message ID: %v", err)
+	}
+	return time.Now().UTC().Format("20060102-150405.000000") + "-" + hex.EncodeToString(random), nil
+}
+
+func containsID(ids []string, id string) bool {
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
+}
+
+func containsMessage(messages []QueuedMessage, id string) bool {
+	for _, queued := range messages {
+		if queued.ID == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,166 @@
+package email
+
+import (
+	"data-insights/kit/common"
+	"data-insights/kit/file"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LoadRecipients reads and validates a recipients configuration file.
+func LoadRecipients(path string) (RecipientsConfig, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return RecipientsConfig{}, fmt.Errorf("failed to read recipients file: %v", err)
+	}
+	var config RecipientsConfig
+	if err := json.Unmarshal(content, &config); err != nil {
+		return RecipientsConfig{}, fmt.Errorf("failed to unmarshal recipients file: %v", err)
+	}
+	if err := config.Validate(); err != nil {
+		return RecipientsConfig{}, err
+	}
+	return config, nil
+}
+
+// SingleRecipient returns a configuration with one recipient who receives every section of every file.
+func SingleRecipient(name, address string) RecipientsConfig {
+	return RecipientsConfig{Recipients: []Recipient{{Name: name, Email: address}}}
+}
+
+// Validate checks that every recipient has an address, that all group, To, Cc and Bcc entries resolve and that
+// every address selected by To is a configured recipient.
+func (c RecipientsConfig) Validate() error {
+	if len(c.Recipients) == 0 {
+		return fmt.Errorf("no recipients configured")
+	}
+	for i, recipient := range c.Recipients {
+		if recipient.Email == "" {
+			return fmt.Errorf("recipient %d has no email", i+1)
+		}
+		for _, section := range recipient.Sections {
+			if !common.IsValidSection(section) {
+				return fmt.Errorf("recipient %s has an unknown section %q", recipient.Email, section)
+			}
+		}
+		for _, pattern := range recipient.Files {
+			if _, err := filepath.Match(pattern, ""); err != nil {
+				return fmt.Errorf("recipient %s has an invalid file pattern %q: %v", recipient.Email, pattern, err)
+			}
+		}
+		if _, err := c.expand(recipient.Cc); err != nil {
+			return fmt.Errorf("recipient %s cc: %v", recipient.Email, err)
+		}
+		if _, err := c.expand(recipient.Bcc); err != nil {
+			return fmt.Errorf("recipient %s bcc: %v", recipient.Email, err)
+		}
+	}
+	for _, entry := range c.To {
+		if _, ok := c.Groups[entry]; !ok && c.find(entry) == nil {
+			return fmt.Errorf("to entry %q is neither a group nor a configured recipient", entry)
+		}
+	}
+	addresses, err := c.expand(c.To)
+	if err != nil {
+		return fmt.Errorf("to: %v", err)
+	}
+	// Groups selected by to must only hold recipients; groups used for cc and bcc may hold any address
+	for _, address := range addresses {
+		if c.find(address) == nil {
+			return fmt.Errorf("to: address %s is not a configured recipient", address)
+		}
+	}
+	return nil
+}
+
+// Deliveries returns one delivery per selected recipient who receives the given data file. Recipients are
+// selected by the To list, or all recipients when it is empty, and filtered by their file patterns.
+func (c RecipientsConfig) Deliveries(fileSource string) ([]Delivery, error) {
+	selected := c.To
+	if len(selected) == 0 {
+		for _, recipient := range c.Recipients {
+			selected = append(selected, recipient.Email)
+		}
+	}
+	addresses, err := c.expand(selected)
+	if err != nil {
+		return nil, err
+	}
+
+	var deliveries []Delivery
+	for _, address := range addresses {
+		recipient := c.find(address)
+		if recipient == nil {
+			return nil, fmt.Errorf("address %s is not a configured recipient", address)
+		}
+		if !recipient.receives(fileSource) {
+			continue
+		}
+		cc, _ := c.expand(recipient.Cc)
+		bcc, _ := c.expand(recipient.Bcc)
+		deliveries = append(deliveries, Delivery{
+			Name:     recipient.Name,
+			To:       recipient.Email,
+			Cc:       cc,
+			Bcc:      bcc,
+			Sections: recipient.Sections,
+		})
+	}
+	return deliveries, nil
+}
+
+// expand resolves group names to addresses, recursively, and removes duplicates while keeping the order.
+func (c RecipientsConfig) expand(entries []string) ([]string, error) {
+	var addresses []string
+	seen := make(map[string]bool)
+	var visit func(entry string, path []string) error
+	visit = func(entry string, path []string) error {
+		members, isGroup := c.Groups[entry]
+		if !isGroup {
+			if !strings.Contains(entry, "@") {
+				return fmt.Errorf("%q is neither a group nor an email address", entry)
+			}
+			if key := strings.ToLower(entry); !seen[key] {
+				seen[key] = true
+				addresses = append(addresses, entry)
+			}
+			return nil
+		}
+		for _, visited := range path {
+			if visited == entry {
+				return fmt.Errorf("group %s includes itself", entry)
+			}
+		}
+		for _, member := range members {
+			if err := visit(member, append(path, entry)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, entry := range entries {
+		if err := visit(entry, nil); err != nil {
+			return nil, err
+		}
+	}
+	return addresses, nil
+}
+
+func (c RecipientsConfig) find(address string) *Recipient {
+	for i := range c.Recipients {
+		if strings.EqualFold(c.Recipients[i].Email, address) {
+			return &c.Recipients[i]
+		}
+	}
+	return nil
+}
+
+// receives reports whether the recipient's file patterns match the data file's base name. A recipient without
+// patterns receives every file.
+func (r Recipient) receives(fileSource string) bool {
+	return file.MatchesAny(r.Files, fileSource)
+}
@@ -3,7 +3,9 @@ package email
 import (
 	"bytes"
 	"data-insights/kit/common"
+	"fmt"
 	"html/template"
+	"path/filepath"
 )
 
 type Renderer interface {
@@ -11,25 +13,40 @@ type Renderer interface {
 }
 
 type EmailRenderer struct {
-	templatePath string
+	templates *template.Template
 }
 
-func NewRenderer(templatePath string) *EmailRenderer {
-	return &EmailRenderer{templatePath: templatePath}
+// NewRenderer parses the templates in templateDir once: the layout and page templates in the directory itself and
+// the section partials in its partials subdirectory. The theme is available to the templates through the theme
+// function. Returns an error if a template does not parse or the layout or content template is missing.
+func NewRenderer(templateDir string, theme Theme) (*EmailRenderer, error) {
+	templates := template.New(filepath.Base(templateDir)).Funcs(templateFuncs(theme))
+	for _, pattern := range []string{filepath.Join(templateDir, "*.html"), filepath.Join(templateDir, PartialsDir, "*.html")} {
+		files, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template directory: %v", err)
+		}
+		if len(files) == 0 {
+			continue
+		}
+		if templates, err = templates.ParseFiles(files...); err != nil {
+			return nil, fmt.Errorf("failed to parse templates: %v", err)
+		}
+	}
+	for _, name := range []string{LayoutTemplate, ContentBlock} {
+		if templates.Lookup(name) == nil {
+			return nil, fmt.Errorf("template %q is not defined in %s", name, templateDir)
+		}
+	}
+	return &EmailRenderer{templates: templates}, nil
 }
 
-// Render parses the email template, executes the parsed template with the provided email data and
-// returns the resulting email body as a string.
+// Render executes the layout template with the provided email data and returns the resulting email body as a
+// string.
 func (r *EmailRenderer) Render(emailData common.EmailData) (string, error) {
-	tmpl, err := template.ParseFiles(r.templatePath)
-	if err != nil {
-		return "", err
-	}
-
 	var body bytes.Buffer
-	if err := tmpl.Execute(&body, emailData); err != nil {
+	if err := r.templates.ExecuteTemplate(&body, LayoutTemplate, emailData); err != nil {
 		return "", err
 	}
-
 	return body.String(), nil
 }
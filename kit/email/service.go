@@ -1,5 +1,5 @@
 package email
 
 type EmailService interface {
-	SendEmail(to string, subject string, body string) error
+	SendEmail(message Message) error
 }
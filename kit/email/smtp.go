@@ -2,60 +2,106 @@ package email
 
 import (
 	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"log"
+	"net"
 	"net/smtp"
+	"os"
 )
 
+type SMTPOptions struct {
+	Security SMTPSecurity
+	Auth     SMTPAuth
+	CAFile   string // PEM bundle trusted in addition to the system roots
+}
+
 type SMTPEmailService struct {
 	SMTPHost     string
 	SMTPPort     string
 	SenderEmail  string
 	SenderPasswd string
+	Security     SMTPSecurity
+	Auth         SMTPAuth
+	TLSConfig    *tls.Config
 }
 
-func NewSMTPEmailService(host, port, email, passwd string) *SMTPEmailService {
+// NewSMTPEmailService creates an SMTP email service. Security defaults to required STARTTLS and authentication
+// to PLAIN. Server certificates are always verified, against the system roots and the optional CA bundle.
+func NewSMTPEmailService(host, port, email, passwd string, options SMTPOptions) (*SMTPEmailService, error) {
+	if options.Security == "" {
+		options.Security = SecurityStartTLS
+	}
+	if options.Auth == "" {
+		options.Auth = AuthPlain
+	}
+
+	switch options.Security {
+	case SecurityImplicitTLS, SecurityStartTLS, SecurityStartTLSOptional, SecurityNone:
+	default:
+		return nil, fmt.Errorf("unknown SMTP security mode: %s", options.Security)
+	}
+	switch options.Auth {
+	case AuthPlain, AuthLogin, AuthCRAMMD5, AuthXOAuth2, AuthNone:
+	default:
+		return nil, fmt.Errorf("unknown SMTP auth mechanism: %s", options.Auth)
+	}
+
+	tlsConfig := &tls.Config{
+		ServerName: host,
+		MinVersion: tls.VersionTLS12,
+	}
+	if options.CAFile != "" {
+		rootCAs, err := loadCertPool(options.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = rootCAs
+	}
+
 	return &SMTPEmailService{
 		SMTPHost:     host,
 		SMTPPort:     port,
 		SenderEmail:  email,
 		SenderPasswd: passwd,
-	}
+		Security:     options.Security,
+		Auth:         options.Auth,
+		TLSConfig:    tlsConfig,
+	}, nil
 }
 
-// SendEmail sends an email to the specified recipient with the provided subject and body.
-// It connects to the SMTP server, secures the connection with TLS, authenticates, and sends the email.
-func (s *SMTPEmailService) SendEmail(to string, subject string, body string) error {
-	// Set up authentication information.
-	auth := smtp.PlainAuth("", s.SenderEmail, s.SenderPasswd, s.SMTPHost)
-
-	// Connect to the SMTP server.
-	client, err := smtp.Dial(s.SMTPHost + ":" + s.SMTPPort)
+// SendEmail sends the message to all of its To, Cc and Bcc recipients. It connects to the SMTP server,
+// secures the connection according to the security mode, authenticates, and sends the email.
+// Bcc recipients are not listed in the headers.
+func (s *SMTPEmailService) SendEmail(message Message) error {
+	// Build the MIME message with a plain-text alternative.
+	msg, err := BuildMessage(s.SenderEmail, message)
 	if err != nil {
-		return fmt.Errorf("failed to connect to SMTP server: %v", err)
-	}
-	defer client.Quit()
-
-	// Upgrade the connection to TLS.
-	tlsConfig := &tls.Config{
-		InsecureSkipVerify: true, // TODO Change in production
-		ServerName:         s.SMTPHost,
+		return fmt.Errorf("failed to build message: %v", err)
 	}
 
-	if err = client.StartTLS(tlsConfig); err != nil {
-		return fmt.Errorf("failed to start TLS: %v", err)
+	// Connect to the SMTP server and secure the connection.
+	client, err := s.connect()
+	if err != nil {
+		return err
 	}
+	defer client.Quit()
 
 	// Authenticate.
-	if err = client.Auth(auth); err != nil {
-		return fmt.Errorf("failed to authenticate to SMTP server: %v", err)
+	if err = s.authenticate(client); err != nil {
+		return err
 	}
 
-	// Set the sender and recipient.
+	// Set the sender and recipients.
 	if err = client.Mail(s.SenderEmail); err != nil {
 		return fmt.Errorf("failed to set sender: %v", err)
 	}
-	if err = client.Rcpt(to); err != nil {
-		return fmt.Errorf("failed to set recipient: %v", err)
+	for _, recipients := range [][]string{message.To, message.Cc, message.Bcc} {
+		for _, recipient := range recipients {
+			if err = client.Rcpt(recipient); err != nil {
+				return fmt.Errorf("failed to set recipient %s: %v", recipient, err)
+			}
+		}
 	}
 
 	// Get the writer for the email data.
@@ -64,12 +110,8 @@ func (s *SMTPEmailService) SendEmail(to string, subject string, body string) err
 		return fmt.Errorf("failed to send data: %v", err)
 	}
 
-	// Format the email with the correct MIME type for HTML.
-	msg := fmt.Sprintf("From: %s\nTo: %s\nSubject: %s\nMIME-version: 1.0;\nContent-Type: text/html; charset=\"UTF-8\";\n\n%s",
-		s.SenderEmail, to, subject, body)
-
 	// Write the email content.
-	if _, err = w.Write([]byte(msg)); err != nil {
+	if _, err = w.Write(msg); err != nil {
 		return fmt.Errorf("failed to write message: %v", err)
 	}
 
@@ -80,3 +122,88 @@ func (s *SMTPEmailService) SendEmail(to string, subject string, body string) err
 
 	return nil
 }
+
+// connect dials the SMTP server. Implicit TLS wraps the connection from the start; the STARTTLS modes upgrade it
+// after the greeting, failing if the server does not offer STARTTLS unless it is optional.
+func (s *SMTPEmailService) connect() (*smtp.Client, error) {
+	address := net.JoinHostPort(s.SMTPHost, s.SMTPPort)
+
+	if s.Security == SecurityImplicitTLS {
+		conn, err := tls.Dial("tcp", address, s.TLSConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to SMTP server over TLS: %v", err)
+		}
+		client, err := smtp.NewClient(conn, s.SMTPHost)
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to create SMTP client: %v", err)
+		}
+		return client, nil
+	}
+
+	client, err := smtp.Dial(address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to SMTP server: %v", err)
+	}
+	if s.Security == SecurityNone {
+		return client, nil
+	}
+
+	if ok, _ := client.Extension("STARTTLS"); !ok {
+		if s.Security == SecurityStartTLSOptional {
+			log.Printf("SMTP server %s does not support STARTTLS, continuing without TLS", s.SMTPHost)
+			return client, nil
+		}
+		client.Close()
+		return nil, fmt.Errorf("SMTP server %s does not support STARTTLS", s.SMTPHost)
+	}
+
+	if err = client.StartTLS(s.TLSConfig); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to start TLS: %v", err)
+	}
+	return client, nil
+}
+
+// authenticate authenticates with the configured mechanism. It is a no-op when authentication is disabled.
+func (s *SMTPEmailService) authenticate(client *smtp.Client) error {
+	if s.Auth == AuthNone {
+		return nil
+	}
+	if ok, _ := client.Extension("AUTH"); !ok {
+		return fmt.Errorf("SMTP server %s does not support authentication", s.SMTPHost)
+	}
+
+	var auth smtp.Auth
+	switch s.Auth {
+	case AuthPlain:
+		auth = smtp.PlainAuth("", s.SenderEmail, s.SenderPasswd, s.SMTPHost)
+	case AuthLogin:
+		auth = LoginAuth(s.SenderEmail, s.SenderPasswd)
+	case AuthCRAMMD5:
+		auth = smtp.CRAMMD5Auth(s.SenderEmail, s.SenderPasswd)
+	case AuthXOAuth2:
+		auth = XOAuth2Auth(s.SenderEmail, s.SenderPasswd)
+	}
+
+	if err := client.Auth(auth); err != nil {
+		return fmt.Errorf("failed to authenticate to SMTP server: %v", err)
+	}
+	return nil
+}
+
+// loadCertPool returns the system roots extended with the certificates in the PEM bundle at path.
+func loadCertPool(path string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA bundle: %v", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in CA bundle %s", path)
+	}
+	return pool, nil
+}
@@ -0,0 +1,425 @@
+package email
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	testUser     = "sender@example.com"
+	testPassword = "s3cret"
+)
+
+// session is what the fake server saw of one delivered message.
+type session struct {
+	TLS       bool
+	Mechanism string
+	Username  string
+	Secret    string
+	From      string
+	To        []string
+	Data      string
+}
+
+// fakeSMTPServer is an in-process SMTP server that accepts every message and records the session. It speaks
+// implicit TLS or plaintext, optionally advertises STARTTLS and offers PLAIN, LOGIN, CRAM-MD5 and XOAUTH2.
+type fakeSMTPServer struct {
+	listener    net.Listener
+	tlsConfig   *tls.Config
+	implicitTLS bool
+	startTLS    bool
+	sessions    chan session
+}
+
+func newFakeSMTPServer(t *testing.T, cert tls.Certificate, implicitTLS, startTLS bool) *fakeSMTPServer {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	server := &fakeSMTPServer{
+		listener:    listener,
+		tlsConfig:   &tls.Config{Certificates: []tls.Certificate{cert}},
+		implicitTLS: implicitTLS,
+		startTLS:    startTLS,
+		sessions:    make(chan session, 1),
+	}
+	t.Cleanup(func() { listener.Close() })
+	go server.serve()
+	return server
+}
+
+func (s *fakeSMTPServer) port() string {
+	return strconv.Itoa(s.listener.Addr().(*net.TCPAddr).Port)
+}
+
+func (s *fakeSMTPServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeSMTPServer) handle(conn net.Conn) {
+	defer func() { conn.Close() }()
+	_ = conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+	var current session
+	if s.implicitTLS {
+		conn = tls.Server(conn, s.tlsConfig)
+		current.TLS = true
+	}
+	reader := bufio.NewReader(conn)
+	reply := func(lines ...string) {
+		for _, line := range lines {
+			if _, err := conn.Write([]byte(line + "\r\n")); err != nil {
+				return
+			}
+		}
+	}
+	readLine := func() (string, bool) {
+		line, err := reader.ReadString('\n')
+		return strings.TrimRight(line, "\r\n"), err == nil
+	}
+
+	reply("220 fake ESMTP")
+	for {
+		line, ok := readLine()
+		if !ok {
+			return
+		}
+		command, argument, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(command) {
+		case "EHLO", "HELO":
+			lines := []string{"250-fake"}
+			if s.startTLS && !current.TLS {
+				lines = append(lines, "250-STARTTLS")
+			}
+			reply(append(lines, "250-AUTH PLAIN LOGIN CRAM-MD5 XOAUTH2", "250 8BITMIME")...)
+		case "STARTTLS":
+			if !s.startTLS || current.TLS {
+				reply("502 5.5.1 not supported")
+				continue
+			}
+			reply("220 2.0.0 ready to start TLS")
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn, current.TLS = tlsConn, true
+			reader = bufio.NewReader(conn)
+		case "AUTH":
+			if s.authenticate(&current, argument, reply, readLine) {
+				reply("235 2.7.0 authentication successful")
+			} else {
+				reply("535 5.7.8 authentication failed")
+			}
+		case "MAIL":
+			current.From = mailboxArgument(argument, "FROM:")
+			reply("250 2.1.0 ok")
+		case "RCPT":
+			current.To = append(current.To, mailboxArgument(argument, "TO:"))
+			reply("250 2.1.5 ok")
+		case "DATA":
+			reply("354 end data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				dataLine, ok := readLine()
+				if !ok {
+					return
+				}
+				if dataLine == "." {
+					break
+				}
+				data.WriteString(dataLine + "\n")
+			}
+			current.Data = data.String()
+			reply("250 2.0.0 queued")
+			s.sessions <- current
+		case "RSET", "NOOP":
+			reply("250 2.0.0 ok")
+		case "QUIT":
+			reply("221 2.0.0 bye")
+			return
+		default:
+			reply("500 5.5.2 unknown command")
+		}
+	}
+}
+
+// mailboxArgument returns the address of a MAIL or RCPT argument, without its parameters such as BODY=8BITMIME.
+func mailboxArgument(argument, prefix string) string {
+	address, _, _ := strings.Cut(strings.TrimPrefix(argument, prefix), " ")
+	return strings.Trim(address, "<>")
+}
+
+// authenticate runs the exchange of the mechanism and reports whether the credentials are the test credentials.
+func (s *fakeSMTPServer) authenticate(current *session, argument string, reply func(...string), readLine func() (string, bool)) bool {
+	mechanism, initial, _ := strings.Cut(argument, " ")
+	current.Mechanism = strings.ToUpper(mechanism)
+	challenge := func(prompt string) (string, bool) {
+		reply("334 " + base64.StdEncoding.EncodeToString([]byte(prompt)))
+		line, ok := readLine()
+		if !ok {
+			return "", false
+		}
+		decoded, err := base64.StdEncoding.DecodeString(line)
+		return string(decoded), err == nil
+	}
+
+	switch current.Mechanism {
+	case "PLAIN":
+		decoded, err := base64.StdEncoding.DecodeString(initial)
+		if err != nil {
+			return false
+		}
+		parts := strings.Split(string(decoded), "\x00")
+		if len(parts) != 3 {
+			return false
+		}
+		current.Username, current.Secret = parts[1], parts[2]
+		return current.Username == testUser && current.Secret == testPassword
+	case "LOGIN":
+		var ok bool
+		if current.Username, ok = challenge("Username:"); !ok {
+			return false
+		}
+		if current.Secret, ok = challenge("Password:"); !ok {
+			return false
+		}
+		return current.Username == testUser && current.Secret == testPassword
+	case "CRAM-MD5":
+		nonce := "<1896.697170952@fake.example.com>"
+		response, ok := challenge(nonce)
+		if !ok {
+			return false
+		}
+		username, digest, _ := strings.Cut(response, " ")
+		mac := hmac.New(md5.New, []byte(testPassword))
+		mac.Write([]byte(nonce))
+		current.Username, current.Secret = username, digest
+		return username == testUser && digest == hex.EncodeToString(mac.Sum(nil))
+	case "XOAUTH2":
+		decoded, err := base64.StdEncoding.DecodeString(initial)
+		if err != nil {
+			return false
+		}
+		for _, field := range strings.Split(string(decoded), "\x01") {
+			if value, ok := strings.CutPrefix(field, "user="); ok {
+				current.Username = value
+			}
+			if value, ok := strings.CutPrefix(field, "auth=Bearer "); ok {
+				current.Secret = value
+			}
+		}
+		return current.Username == testUser && current.Secret == testPassword
+	}
+	return false
+}
+
+// newTestCertificate returns a self-signed certificate for 127.0.0.1 and its PEM encoding.
+func newTestCertificate(t *testing.T) (tls.Certificate, []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "fake SMTP server"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+// writeCAFile writes the PEM certificate to a temporary CA bundle and returns its path.
+func writeCAFile(t *testing.T, certPEM []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(path, certPEM, 0o644); err != nil {
+		t.Fatalf("failed to write CA bundle: %v", err)
+	}
+	return path
+}
+
+func testMessage() Message {
+	return Message{To: []string{"to@example.com"}, Cc: []string{"cc@example.com"}, Bcc: []string{"bcc@example.com"}, Subject: "Report", Body: "<p>Hello</p>"}
+}
+
+// send sends the test message through a service for the server and returns the session the server recorded.
+func send(t *testing.T, server *fakeSMTPServer, options SMTPOptions, password string) (session, error) {
+	t.Helper()
+	service, err := NewSMTPEmailService("127.0.0.1", server.port(), testUser, password, options)
+	if err != nil {
+		t.Fatalf("failed to create SMTP service: %v", err)
+	}
+	if err := service.SendEmail(testMessage()); err != nil {
+		return session{}, err
+	}
+	select {
+	case received := <-server.sessions:
+		return received, nil
+	case <-time.After(5 * time.Second):
+		t.Fatal("the server did not receive the message")
+		return session{}, nil
+	}
+}
+
+func TestSendEmailSecurityModes(t *testing.T) {
+	cert, certPEM := newTestCertificate(t)
+	caFile := writeCAFile(t, certPEM)
+
+	tests := []struct {
+		name        string
+		security    SMTPSecurity
+		implicitTLS bool
+		startTLS    bool
+		wantTLS     bool
+		wantErr     string
+	}{
+		{name: "implicit TLS", security: SecurityImplicitTLS, implicitTLS: true, wantTLS: true},
+		{name: "STARTTLS offered", security: SecurityStartTLS, startTLS: true, wantTLS: true},
+		{name: "STARTTLS not offered", security: SecurityStartTLS, wantErr: "does not support STARTTLS"},
+		{name: "optional STARTTLS offered", security: SecurityStartTLSOptional, startTLS: true, wantTLS: true},
+		{name: "optional STARTTLS not offered", security: SecurityStartTLSOptional, wantTLS: false},
+		{name: "no TLS with STARTTLS offered", security: SecurityNone, startTLS: true, wantTLS: false},
+		{name: "no TLS without STARTTLS", security: SecurityNone, wantTLS: false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := newFakeSMTPServer(t, cert, test.implicitTLS, test.startTLS)
+			received, err := send(t, server, SMTPOptions{Security: test.security, CAFile: caFile}, testPassword)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("got error %v, want one containing %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to send email: %v", err)
+			}
+			if received.TLS != test.wantTLS {
+				t.Errorf("got TLS %v, want %v", received.TLS, test.wantTLS)
+			}
+			if received.From != testUser {
+				t.Errorf("got sender %q, want %q", received.From, testUser)
+			}
+			if want := []string{"to@example.com", "cc@example.com", "bcc@example.com"}; strings.Join(received.To, ",") != strings.Join(want, ",") {
+				t.Errorf("got recipients %v, want %v", received.To, want)
+			}
+			if strings.Contains(received.Data, "bcc@example.com") {
+				t.Error("the Bcc recipient is listed in the message")
+			}
+		})
+	}
+}
+
+func TestSendEmailAuthMechanisms(t *testing.T) {
+	cert, certPEM := newTestCertificate(t)
+	caFile := writeCAFile(t, certPEM)
+
+	tests := []struct {
+		auth          SMTPAuth
+		wantMechanism string
+	}{
+		{auth: AuthPlain, wantMechanism: "PLAIN"},
+		{auth: AuthLogin, wantMechanism: "LOGIN"},
+		{auth: AuthCRAMMD5, wantMechanism: "CRAM-MD5"},
+		{auth: AuthXOAuth2, wantMechanism: "XOAUTH2"},
+	}
+	for _, test := range tests {
+		t.Run(string(test.auth), func(t *testing.T) {
+			server := newFakeSMTPServer(t, cert, false, true)
+			received, err := send(t, server, SMTPOptions{Auth: test.auth, CAFile: caFile}, testPassword)
+			if err != nil {
+				t.Fatalf("failed to send email: %v", err)
+			}
+			if received.Mechanism != test.wantMechanism {
+				t.Errorf("got mechanism %q, want %q", received.Mechanism, test.wantMechanism)
+			}
+			if received.Username != testUser {
+				t.Errorf("got username %q, want %q", received.Username, testUser)
+			}
+
+			if _, err := send(t, server, SMTPOptions{Auth: test.auth, CAFile: caFile}, "wrong"); err == nil || !strings.Contains(err.Error(), "failed to authenticate") {
+				t.Errorf("got error %v with a wrong password, want an authentication failure", err)
+			}
+		})
+	}
+
+	t.Run(string(AuthNone), func(t *testing.T) {
+		server := newFakeSMTPServer(t, cert, false, true)
+		received, err := send(t, server, SMTPOptions{Auth: AuthNone, CAFile: caFile}, "")
+		if err != nil {
+			t.Fatalf("failed to send email: %v", err)
+		}
+		if received.Mechanism != "" {
+			t.Errorf("got mechanism %q, want no authentication", received.Mechanism)
+		}
+	})
+}
+
+func TestSendEmailVerifiesCertificates(t *testing.T) {
+	cert, certPEM := newTestCertificate(t)
+
+	for _, security := range []SMTPSecurity{SecurityImplicitTLS, SecurityStartTLS} {
+		t.Run(string(security), func(t *testing.T) {
+			server := newFakeSMTPServer(t, cert, security == SecurityImplicitTLS, true)
+			_, err := send(t, server, SMTPOptions{Security: security}, testPassword)
+			if err == nil || !strings.Contains(err.Error(), "certificate") {
+				t.Fatalf("got error %v for a self-signed certificate, want a certificate error", err)
+			}
+
+			if _, err := send(t, server, SMTPOptions{Security: security, CAFile: writeCAFile(t, certPEM)}, testPassword); err != nil {
+				t.Fatalf("failed to send email with the CA bundle: %v", err)
+			}
+		})
+	}
+}
+
+func TestNewSMTPEmailServiceRejectsInvalidOptions(t *testing.T) {
+	if _, err := NewSMTPEmailService("127.0.0.1", "25", testUser, testPassword, SMTPOptions{Security: "ssl"}); err == nil {
+		t.Error("an unknown security mode was accepted")
+	}
+	if _, err := NewSMTPEmailService("127.0.0.1", "25", testUser, testPassword, SMTPOptions{Auth: "ntlm"}); err == nil {
+		t.Error("an unknown auth mechanism was accepted")
+	}
+	emptyBundle := filepath.Join(t.TempDir(), "empty.pem")
+	if err := os.WriteFile(emptyBundle, []byte("not a certificate"), 0o644); err != nil {
+		t.Fatalf("failed to write CA bundle: %v", err)
+	}
+	if _, err := NewSMTPEmailService("127.0.0.1", "25", testUser, testPassword, SMTPOptions{CAFile: emptyBundle}); err == nil {
+		t.Error("a CA bundle without certificates was accepted")
+	}
+}
@@ -0,0 +1,42 @@
+package email
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	invisibleElements = regexp.MustCompile(`(?is)<(head|style|script)[^>]*>.*?</(head|style|script)>`)
+	comments          = regexp.MustCompile(`(?s)<!--.*?-->`)
+	blockEnds         = regexp.MustCompile(`(?i)</(p|h[1-6]|table|ul|ol)>`)
+	lineBreaks        = regexp.MustCompile(`(?i)<br\s*/?>|</(div|tr|li)>`)
+	listItems         = regexp.MustCompile(`(?i)<li[^>]*>`)
+	cellEnds          = regexp.MustCompile(`(?i)</(td|th)>`)
+	tags              = regexp.MustCompile(`<[^>]*>`)
+	spaces            = regexp.MustCompile(`[ \t]+`)
+	blankLines        = regexp.MustCompile(`\n{3,}`)
+)
+
+// HTMLToText converts an HTML email body to a readable plain-text alternative. Paragraphs, headings and tables
+// are separated by blank lines, table cells by a vertical bar, and entities are decoded.
+func HTMLToText(body string) string {
+	text := invisibleElements.ReplaceAllString(body, "")
+	text = comments.ReplaceAllString(text, "")
+	text = strings.NewReplacer("\r\n", " ", "\n", " ").Replace(text)
+	text = blockEnds.ReplaceAllString(text, "\n\n")
+	text = lineBreaks.ReplaceAllString(text, "\n")
+	text = listItems.ReplaceAllString(text, "- ")
+	text = cellEnds.ReplaceAllString(text, " | ")
+	text = tags.ReplaceAllString(text, "")
+	text = html.UnescapeString(text)
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		line = spaces.ReplaceAllString(line, " ")
+		lines[i] = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(line), "|"))
+	}
+	text = strings.Join(lines, "\n")
+	text = blankLines.ReplaceAllString(text, "\n\n")
+	return strings.TrimSpace(text) + "\n"
+}
@@ -0,0 +1,107 @@
+package email
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"os"
+	"regexp"
+	"strings"
+)
+
+var colourPattern = regexp.MustCompile(`^#(?:[0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// DefaultTheme returns the unbranded theme, with thresholds for the rates and durations in the report.
+func DefaultTheme() Theme {
+	return Theme{
+		PrimaryColour: "#333333",
+		TextColour:    "#555555",
+		AccentColour:  "#f2f2f2",
+		BorderColour:  "#dddddd",
+		GoodColour:    "#2e7d32",
+		WarningColour: "#ef6c00",
+		BadColour:     "#c62828",
+		Thresholds: map[string]Threshold{
+			"overall_engagement_rate":  {Good: 60, Bad: 40},
+			"average_engagement_rate":  {Good: 60, Bad: 40},
+			"bounce_rate":              {Good: 35, Bad: 55},
+			"average_session_duration": {Good: 120, Bad: 30},
+		},
+	}
+}
+
+// LoadTheme reads a theme file over the default theme, so that it only needs the settings it changes.
+func LoadTheme(path string) (Theme, error) {
+	theme := DefaultTheme()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return Theme{}, fmt.Errorf("failed to read theme file: %v", err)
+	}
+	if err := json.Unmarshal(content, &theme); err != nil {
+		return Theme{}, fmt.Errorf("failed to unmarshal theme file: %v", err)
+	}
+	if err := theme.Validate(); err != nil {
+		return Theme{}, err
+	}
+	return theme, nil
+}
+
+// Validate checks that the colours are hex colours and that the logo is an http(s) or image data URL, since both
+// are written into the template's CSS and attributes.
+func (t Theme) Validate() error {
+	colours := map[string]string{
+		"primary_colour": t.PrimaryColour, "text_colour": t.TextColour, "accent_colour": t.AccentColour,
+		"border_colour": t.BorderColour, "good_colour": t.GoodColour, "warning_colour": t.WarningColour,
+		"bad_colour": t.BadColour,
+	}
+	for name, colour := range colours {
+		if !colourPattern.MatchString(colour) {
+			return fmt.Errorf("theme %s %q is not a hex colour", name, colour)
+		}
+	}
+	if t.LogoURL != "" && !strings.HasPrefix(t.LogoURL, "https://") && !strings.HasPrefix(t.LogoURL, "http://") &&
+		!strings.HasPrefix(t.LogoURL, "data:image/") {
+		return fmt.Errorf("theme logo_url must be an http(s) URL or an image data URL")
+	}
+	return nil
+}
+
+// Status classifies a value against the metric's threshold as "good", "warning" or "bad". Metrics without a
+// threshold and values that are not numbers have no status.
+func (t Theme) Status(metric string, value interface{}) string {
+	threshold, ok := t.Thresholds[metric]
+	number, isNumber := toNumber(value)
+	if !ok || !isNumber {
+		return ""
+	}
+	// Lower is better when the good threshold is below the bad one, as for bounce rates
+	higherIsBetter := threshold.Good >= threshold.Bad
+	switch {
+	case higherIsBetter && number >= threshold.Good, !higherIsBetter && number <= threshold.Good:
+		return StatusGood
+	case higherIsBetter && number <= threshold.Bad, !higherIsBetter && number >= threshold.Bad:
+		return StatusBad
+	default:
+		return StatusWarning
+	}
+}
+
+// StatusColour returns the theme colour for the value's status, or the text colour if it has none.
+func (t Theme) StatusColour(metric string, value interface{}) string {
+	switch t.Status(metric, value) {
+	case StatusGood:
+		return t.GoodColour
+	case StatusWarning:
+		return t.WarningColour
+	case StatusBad:
+		return t.BadColour
+	default:
+		return t.TextColour
+	}
+}
+
+// Logo returns the logo URL for use in templates. It is trusted, since Validate only accepts http(s) and image
+// data URLs.
+func (t Theme) Logo() template.URL {
+	return template.URL(t.LogoURL)
+}
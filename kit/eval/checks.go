@@ -0,0 +1,180 @@
+package eval
+
+import (
+	"data-insights/kit/common"
+	"data-insights/kit/metrics"
+	"fmt"
+	"strings"
+)
+
+type section struct {
+	name     common.Section
+	insight  common.AggregatedMetricsWithInsight
+	computed common.AggregatedMetricsList
+	value    func(metric common.AggregatedMetric) string
+	expected func(metric common.AggregatedMetrics) float64
+	isRate   bool
+}
+
+// sections pairs every aggregated section of the LLM output with the metrics it was generated from.
+func sections(output common.UserMetricsWithInsights, computed common.UserMetrics) []section {
+	engagementRate := func(m common.AggregatedMetric) string { return m.AverageEngagementRate }
+	bounceRate := func(m common.AggregatedMetric) string { return m.BounceRate }
+	sessions := func(m common.AggregatedMetric) string { return m.TotalSessions }
+	duration := func(m common.AggregatedMetric) string { return m.AverageSessionDuration }
+
+	return []section{
+		{common.TOPCOUNTRIESSECTION, output.Top5CountriesWithHighestEngagementRate, computed.Top5CountriesWithHighestEngagementRate,
+			engagementRate, func(m common.AggregatedMetrics) float64 { return m.AverageEngagementRate }, true},
+		{common.BOTTOMCOUNTRIESSECTION, output.Top5CountriesWithLowestEngagementRate, computed.Top5CountriesWithLowestEngagementRate,
+			engagementRate, func(m common.AggregatedMetrics) float64 { return m.AverageEngagementRate }, true},
+		{common.BOUNCERATESSECTION, output.BounceRatesByDevices, computed.BounceRatesByDevices,
+			bounceRate, func(m common.AggregatedMetrics) float64 { return m.BounceRate }, false},
+		{common.TOPPAGESSECTION, output.Top5PagesWithHighestNoOfSessions, computed.Top5PagesWithHighestNoOfSessions,
+			sessions, func(m common.AggregatedMetrics) float64 { return float64(m.TotalSessions) }, false},
+		{common.BOTTOMPAGESSECTION, output.Top5PagesWithLowestNoOfSessions, computed.Top5PagesWithLowestNoOfSessions,
+			sessions, func(m common.AggregatedMetrics) float64 { return float64(m.TotalSessions) }, false},
+		{common.SESSIONDURATIONSSECTION, output.AverageSessionDurationsByDevices, computed.AverageSessionDurationsByDevices,
+			duration, func(m common.AggregatedMetrics) float64 { return m.AverageSessionDuration }, false},
+	}
+}
+
+// checkNumbersMatch verifies that every value the model echoed back matches the computed metrics.
+func checkNumbersMatch(output common.UserMetricsWithInsights, computed common.UserMetrics, tolerance float64) CheckResult {
+	var problems []string
+
+	overall := computed.OverallMetrics
+	overallValues := []struct {
+		name     string
+		value    string
+		expected float64
+		isRate   bool
+	}{
+		{"overall_engagement_rate", output.OverallMetrics.OverallEngagementRate, overall.OverallEngagementRate, true},
+		{"average_session_duration", output.OverallMetrics.AverageSessionDuration, overall.AverageSessionDuration, false},
+		{"bounce_rate", output.OverallMetrics.BounceRate, overall.BounceRate, false},
+		{"pages_per_session", output.OverallMetrics.PagesPerSession, overall.PagesPerSession, false},
+		{"new_user_percentage", output.OverallMetrics.NewUserPercentage, overall.NewUserPercentage, false},
+		{"session_per_user", output.OverallMetrics.SessionPerUser, overall.SessionPerUser, false},
+	}
+	for _, v := range overallValues {
+		if problem := compareValue(v.value, v.expected, v.isRate, tolerance); problem != "" {
+			problems = append(problems, fmt.Sprintf("overall_metrics.%s: %s", v.name, problem))
+		}
+	}
+
+	for _, s := range sections(output, computed) {
+		if len(s.insight.AggregatedMetrics) != len(s.computed) {
+			problems = append(problems, fmt.Sprintf("%s: expected %d rows, got %d", s.name, len(s.computed), len(s.insight.AggregatedMetrics)))
+			continue
+		}
+		for i, row := range s.insight.AggregatedMetrics {
+			if row.Name != s.computed[i].Name {
+				problems = append(problems, fmt.Sprintf("%s[%d]: expected name %q, got %q", s.name, i, s.computed[i].Name, row.Name))
+				continue
+			}
+			if problem := compareValue(s.value(row), s.expected(s.computed[i]), s.isRate, tolerance); problem != "" {
+				problems = append(problems, fmt.Sprintf("%s[%s]: %s", s.name, row.Name, problem))
+			}
+		}
+	}
+
+	for _, recommendation := range output.ExecutiveSummary.Recommendations {
+		for _, evidence := range recommendation.Evidence {
+			expected, isRate, ok := metrics.LookupValue(computed, evidence.Section, evidence.Name, evidence.Metric)
+			if !ok {
+				problems = append(problems, fmt.Sprintf("recommendation %d: unknown evidence %s %s %s", recommendation.Rank, evidence.Section, evidence.Name, evidence.Metric))
+				continue
+			}
+			if problem := compareValue(evidence.Value, expected, isRate, tolerance); problem != "" {
+				problems = append(problems, fmt.Sprintf("recommendation %d evidence %s %s: %s", recommendation.Rank, evidence.Name, evidence.Metric, problem))
+			}
+		}
+	}
+
+	return newCheckResult(CheckNumbersMatch, problems)
+}
+
+// checkInsightPresent verifies that every section carries a non-empty insight.
+func checkInsightPresent(output common.UserMetricsWithInsights, computed common.UserMetrics) CheckResult {
+	var problems []string
+	for _, i := range insights(output, computed) {
+		name, insight := i.name, i.insight
+		if strings.TrimSpace(insight) == "" {
+			problems = append(problems, fmt.Sprintf("%s: missing insight", name))
+		}
+	}
+	return newCheckResult(CheckInsightPresent, problems)
+}
+
+// checkInsightLength verifies that every non-empty insight stays within the configured length limits.
+func checkInsightLength(output common.UserMetricsWithInsights, computed common.UserMetrics, limits Limits) CheckResult {
+	var problems []string
+	for _, i := range insights(output, computed) {
+		name, insight := i.name, i.insight
+		length := len([]rune(strings.TrimSpace(insight)))
+		if length == 0 {
+			continue
+		}
+		if length < limits.MinInsightLength || length > limits.MaxInsightLength {
+			problems = append(problems, fmt.Sprintf("%s: %d characters, expected %d-%d", name, length, limits.MinInsightLength, limits.MaxInsightLength))
+		}
+	}
+	return newCheckResult(CheckInsightLength, problems)
+}
+
+// checkBannedPhrases verifies that no insight contains any of the banned phrases, ignoring case.
+func checkBannedPhrases(output common.UserMetricsWithInsights, computed common.UserMetrics, bannedPhrases []string) CheckResult {
+	var problems []string
+	for _, i := range insights(output, computed) {
+		name, insight := i.name, i.insight
+		lower := strings.ToLower(insight)
+		for _, phrase := range bannedPhrases {
+			if strings.Contains(lower, strings.ToLower(phrase)) {
+				problems = append(problems, fmt.Sprintf("%s: contains %q", name, phrase))
+			}
+		}
+	}
+	return newCheckResult(CheckBannedPhrases, problems)
+}
+
+type namedInsight struct {
+	name    common.Section
+	insight string
+}
+
+// insights returns the insight of every section together with its JSON name, in report order.
+func insights(output common.UserMetricsWithInsights, computed common.UserMetrics) []namedInsight {
+	result := []namedInsight{
+		{"executive_summary", output.ExecutiveSummary.Summary},
+		{common.OVERALLSECTION, output.OverallMetrics.AIInsight},
+	}
+	for _, s := range sections(output, computed) {
+		result = append(result, namedInsight{s.name, s.insight.AIInsight})
+	}
+	return result
+}
+
+// compareValue parses the first number in value and compares it with expected using a relative tolerance.
+// Rates are accepted both as a fraction and as a percentage. Returns a description of the mismatch or an empty string.
+func compareValue(value string, expected float64, isRate bool, tolerance float64) string {
+	got, ok := metrics.ParseNumber(value)
+	if !ok {
+		return fmt.Sprintf("no number in %q", value)
+	}
+	if metrics.ValueMatches(got, expected, isRate, tolerance) {
+		return ""
+	}
+	if isRate {
+		expected *= 100
+	}
+	return fmt.Sprintf("expected %.2f, got %q", expected, value)
+}
+
+func newCheckResult(name string, problems []string) CheckResult {
+	return CheckResult{
+		Name:   name,
+		Passed: len(problems) == 0,
+		Detail: strings.Join(problems, "; "),
+	}
+}
@@ -0,0 +1,46 @@
+package eval
+
+const (
+	FixtureDir   = "eval/fixtures"
+	RecordedDir  = "eval/recorded"
+	BaselineFile = "eval/baseline.json"
+)
+
+const (
+	CheckValidJSON      = "valid_json"
+	CheckNumbersMatch   = "numbers_match"
+	CheckInsightPresent = "insight_present"
+	CheckInsightLength  = "insight_length"
+	CheckBannedPhrases  = "banned_phrases"
+)
+
+// Regressions that are not a single check: a fixture missing from the current run, or a lower fixture score.
+const (
+	CheckMissing = "missing"
+	CheckScore   = "score"
+)
+
+const (
+	defaultMinInsightLength = 40
+	defaultMaxInsightLength = 600
+	defaultTolerance        = 0.01
+)
+
+var defaultBannedPhrases = []string{
+	"as an ai",
+	"language model",
+	"i cannot",
+	"lorem ipsum",
+	"it is important to note",
+	"in conclusion",
+}
+
+// DefaultLimits returns the limits used by the evaluation command when none are overridden.
+func DefaultLimits() Limits {
+	return Limits{
+		MinInsightLength: defaultMinInsightLength,
+		MaxInsightLength: defaultMaxInsightLength,
+		BannedPhrases:    defaultBannedPhrases,
+		Tolerance:        defaultTolerance,
+	}
+}
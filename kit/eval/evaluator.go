@@ -0,0 +1,214 @@
+package eval
+
+import (
+	"data-insights/kit/ai"
+	"data-insights/kit/common"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Generator produces the raw LLM output for a fixture.
+type Generator interface {
+	Generate(fixture Fixture) (string, error)
+}
+
+// LiveGenerator sends fixtures to the LLM. When RecordDir is set, every response is stored there
+// so that later runs can be replayed offline.
+type LiveGenerator struct {
+	Client    ai.Client
+	ApiKey    string
+	RecordDir string
+}
+
+func NewLiveGenerator(client ai.Client, apiKey, recordDir string) LiveGenerator {
+	return LiveGenerator{Client: client, ApiKey: apiKey, RecordDir: recordDir}
+}
+
+func (g LiveGenerator) Generate(fixture Fixture) (string, error) {
+	output, err := g.Client.GetInsightsFromLLM(g.ApiKey, fixture.Metrics)
+	if err != nil {
+		return "", err
+	}
+	if g.RecordDir != "" {
+		if err := os.MkdirAll(g.RecordDir, 0o755); err != nil {
+			return "", fmt.Errorf("failed to create record directory: %v", err)
+		}
+		if err := os.WriteFile(recordedPath(g.RecordDir, fixture.Name), []byte(output), 0o644); err != nil {
+			return "", fmt.Errorf("failed to record response: %v", err)
+		}
+	}
+	return output, nil
+}
+
+// RecordedGenerator replays responses previously stored by LiveGenerator.
+type RecordedGenerator struct {
+	Dir string
+}
+
+func NewRecordedGenerator(dir string) RecordedGenerator {
+	return RecordedGenerator{Dir: dir}
+}
+
+func (g RecordedGenerator) Generate(fixture Fixture) (string, error) {
+	content, err := os.ReadFile(recordedPath(g.Dir, fixture.Name))
+	if err != nil {
+		return "", fmt.Errorf("no recorded response for fixture %s: %v", fixture.Name, err)
+	}
+	return string(content), nil
+}
+
+func recordedPath(dir, fixtureName string) string {
+	return filepath.Join(dir, fixtureName+".json")
+}
+
+// LoadFixtures reads every JSON fixture in dir, sorted by name. A fixture without a name is named after its file.
+func LoadFixtures(dir string) ([]Fixture, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list fixtures: %v", err)
+	}
+
+	var fixtures []Fixture
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read fixture %s: %v", path, err)
+		}
+		var fixture Fixture
+		if err := json.Unmarshal(content, &fixture); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal fixture %s: %v", path, err)
+		}
+		if fixture.Name == "" {
+			fixture.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		}
+		fixtures = append(fixtures, fixture)
+	}
+	sort.Slice(fixtures, func(i, j int) bool { return fixtures[i].Name < fixtures[j].Name })
+	return fixtures, nil
+}
+
+// Evaluate runs every fixture through the generator and scores the output with the deterministic checks.
+// A fixture whose output cannot be generated or parsed scores zero.
+func Evaluate(fixtures []Fixture, generator Generator, limits Limits) Report {
+	var report Report
+	for _, fixture := range fixtures {
+		report.Results = append(report.Results, evaluateFixture(fixture, generator, limits))
+	}
+	for _, result := range report.Results {
+		report.Score += result.Score
+	}
+	if len(report.Results) > 0 {
+		report.Score /= float64(len(report.Results))
+	}
+	return report
+}
+
+func evaluateFixture(fixture Fixture, generator Generator, limits Limits) Result {
+	result := Result{Fixture: fixture.Name}
+
+	output, err := generator.Generate(fixture)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	var insights common.UserMetricsWithInsights
+	if err := json.Unmarshal([]byte(output), &insights); err != nil {
+		result.Checks = []CheckResult{{Name: CheckValidJSON, Detail: err.Error()}}
+		return result
+	}
+
+	result.Checks = []CheckResult{
+		{Name: CheckValidJSON, Passed: true},
+		checkNumbersMatch(insights, fixture.Metrics, limits.Tolerance),
+		checkInsightPresent(insights, fixture.Metrics),
+		checkInsightLength(insights, fixture.Metrics, limits),
+		checkBannedPhrases(insights, fixture.Metrics, limits.BannedPhrases),
+	}
+
+	var passed int
+	for _, check := range result.Checks {
+		if check.Passed {
+			passed++
+		}
+	}
+	result.Score = float64(passed) / float64(len(result.Checks))
+	return result
+}
+
+// Compare diffs the current report against a baseline, check by check. A check that passed in the baseline
+// and fails now is a regression; the opposite is an improvement. A baseline fixture that is missing now, or whose
+// score dropped, is a regression too. New fixtures are ignored until they are added to the baseline.
+func Compare(baseline, current Report) Comparison {
+	currentResults := make(map[string]Result)
+	for _, result := range current.Results {
+		currentResults[result.Fixture] = result
+	}
+
+	comparison := Comparison{ScoreDrop: current.Score < baseline.Score}
+	for _, previousResult := range baseline.Results {
+		result, ok := currentResults[previousResult.Fixture]
+		if !ok {
+			comparison.Regressions = append(comparison.Regressions, Diff{Fixture: previousResult.Fixture, Check: CheckMissing})
+			continue
+		}
+		previous, checks := checkMap(previousResult), checkMap(result)
+		regressions := len(comparison.Regressions)
+		for _, name := range []string{CheckValidJSON, CheckNumbersMatch, CheckInsightPresent, CheckInsightLength, CheckBannedPhrases} {
+			was, now := previous[name], checks[name]
+			diff := Diff{Fixture: result.Fixture, Check: name}
+			switch {
+			case was && !now:
+				comparison.Regressions = append(comparison.Regressions, diff)
+			case !was && now:
+				comparison.Improvements = append(comparison.Improvements, diff)
+			}
+		}
+		if len(comparison.Regressions) == regressions && result.Score < previousResult.Score {
+			comparison.Regressions = append(comparison.Regressions, Diff{Fixture: result.Fixture, Check: CheckScore})
+		}
+	}
+	return comparison
+}
+
+// Regressed reports whether the current report is worse than the baseline in any way.
+func (c Comparison) Regressed() bool {
+	return len(c.Regressions) > 0 || c.ScoreDrop
+}
+
+func checkMap(result Result) map[string]bool {
+	checks := make(map[string]bool)
+	for _, check := range result.Checks {
+		checks[check.Name] = check.Passed
+	}
+	return checks
+}
+
+// LoadReport reads a report previously written by SaveReport.
+func LoadReport(path string) (Report, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return Report{}, fmt.Errorf("failed to read report: %v", err)
+	}
+	var report Report
+	if err := json.Unmarshal(content, &report); err != nil {
+		return Report{}, fmt.Errorf("failed to unmarshal report: %v", err)
+	}
+	return report, nil
+}
+
+// SaveReport writes the report as indented JSON so that baselines diff cleanly in version control.
+func SaveReport(path string, report Report) error {
+	content, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal report: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create report directory: %v", err)
+	}
+	return os.WriteFile(path, append(content, '\n'), 0o644)
+}
@@ -0,0 +1,44 @@
+package eval
+
+import "data-insights/kit/common"
+
+type Fixture struct {
+	Name    string             `json:"name"`
+	Metrics common.UserMetrics `json:"metrics"`
+}
+
+type CheckResult struct {
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+	Detail string `json:"detail,omitempty"`
+}
+
+type Result struct {
+	Fixture string        `json:"fixture"`
+	Error   string        `json:"error,omitempty"`
+	Checks  []CheckResult `json:"checks"`
+	Score   float64       `json:"score"`
+}
+
+type Report struct {
+	Results []Result `json:"results"`
+	Score   float64  `json:"score"`
+}
+
+type Diff struct {
+	Fixture string `json:"fixture"`
+	Check   string `json:"check"`
+}
+
+type Comparison struct {
+	Regressions  []Diff `json:"regressions"`
+	Improvements []Diff `json:"improvements"`
+	ScoreDrop    bool   `json:"score_drop"` // the overall score is below the baseline's
+}
+
+type Limits struct {
+	MinInsightLength int
+	MaxInsightLength int
+	BannedPhrases    []string
+	Tolerance        float64
+}
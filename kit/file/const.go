@@ -0,0 +1,37 @@
+package file
+
+import "time"
+
+// SymlinkPolicy selects how symbolic links in the data directory are handled.
+type SymlinkPolicy string
+
+const (
+	SymlinksSkip   SymlinkPolicy = "skip"   // leave out every symbolic link
+	SymlinksFiles  SymlinkPolicy = "files"  // include links to files, but do not descend into linked directories
+	SymlinksFollow SymlinkPolicy = "follow" // include links to files and descend into linked directories
+)
+
+const (
+	DefaultExtensions = ".json" // data files are JSON
+	AllExtensions     = "*"     // extension filter that includes every file
+)
+
+// Schemes of the locations that are not read from the local filesystem.
+const (
+	SchemeS3    = "s3"
+	SchemeHTTP  = "http"
+	SchemeHTTPS = "https"
+)
+
+const (
+	GzipExtension   = ".gz" // compressed data files, which are also recognised by their content
+	DefaultS3Region = "us-east-1"
+	RequestTimeout  = 5 * time.Minute // for every request to object storage or an HTTP source
+
+	s3Service        = "s3"
+	s3Algorithm      = "AWS4-HMAC-SHA256"
+	s3TimeFormat     = "20060102T150405Z"
+	s3DateFormat     = "20060102"
+	emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855" // SHA-256 of no content
+	maxErrorBody     = 1024                                                               // bytes of an error response that are reported
+)
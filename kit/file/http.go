@@ -0,0 +1,45 @@
+package file
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// HTTPSource reads a data file from an HTTP(S) URL, sending the headers with every request, for example to
+// authenticate. A URL is a single data file, since HTTP has no listing.
+type HTTPSource struct {
+	Headers map[string]string
+	Client  *http.Client
+}
+
+func NewHTTPSource(headers map[string]string, client *http.Client) *HTTPSource {
+	return &HTTPSource{Headers: headers, Client: client}
+}
+
+// List returns the URL itself, since HTTP has no listing. The filters do not apply to a URL named explicitly.
+func (s *HTTPSource) List(root string, _ WalkOptions) ([]string, error) {
+	return []string{root}, nil
+}
+
+// Open requests the URL and returns the response body. Returns an error if the request fails or the server
+// responds with an error status.
+func (s *HTTPSource) Open(location string) (io.ReadCloser, error) {
+	req, err := http.NewRequest(http.MethodGet, location, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	for name, value := range s.Headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request %s: %v", location, err)
+	}
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return nil, fmt.Errorf("request for %s failed with status %d: %s", location, resp.StatusCode, body)
+	}
+	return resp.Body, nil
+}
@@ -0,0 +1,42 @@
+package file
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHTTPSourceSendsHeaders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" || r.Header.Get("X-Api-Key") != "key" {
+			http.Error(w, "missing credentials", http.StatusUnauthorized)
+			return
+		}
+		io.WriteString(w, `{"rows":[]}`)
+	}))
+	defer server.Close()
+
+	source := NewHTTPSource(map[string]string{"Authorization": "Bearer token", "X-Api-Key": "key"}, server.Client())
+	content, err := source.Open(server.URL + "/shop.json")
+	if err != nil {
+		t.Fatalf("failed to open: %v", err)
+	}
+	defer content.Close()
+	if data, _ := io.ReadAll(content); string(data) != `{"rows":[]}` {
+		t.Errorf("got content %q", data)
+	}
+
+	_, err = NewHTTPSource(nil, server.Client()).Open(server.URL + "/shop.json")
+	if err == nil || !strings.Contains(err.Error(), "status 401: missing credentials") {
+		t.Errorf("got error %v without credentials, want the status and the response body", err)
+	}
+}
+
+func TestHTTPSourceListsURL(t *testing.T) {
+	locations, err := NewHTTPSource(nil, nil).List("https://example.com/export?day=1", WalkOptions{Extensions: []string{".csv"}})
+	if err != nil || len(locations) != 1 || locations[0] != "https://example.com/export?day=1" {
+		t.Errorf("got locations %v and error %v, want the URL itself", locations, err)
+	}
+}
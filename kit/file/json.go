@@ -1,32 +1,41 @@
 package file
 
 import (
+	"crypto/sha256"
 	"data-insights/kit/common"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
-	"os"
 )
 
-// GetRawDataFromFile reads and parses the JSON file at the given filePath.
-// It returns a slice of Insight objects or an error if something goes wrong.
-func GetRawDataFromFile(filePath string) ([]common.Insight, error) {
-	file, err := os.Open(filePath)
+// GetRawDataFromFile reads and parses the JSON file at the given filePath, from the source that holds it.
+// Gzip-compressed files are decompressed. It returns a slice of Insight objects or an error if something goes wrong.
+func GetRawDataFromFile(source Source, filePath string) ([]common.Insight, error) {
+	data, _, err := GetRawDataWithHash(source, filePath)
+	return data, err
+}
+
+// GetRawDataWithHash reads and parses the JSON file like GetRawDataFromFile, and also returns the hex-encoded
+// SHA-256 hash of its decompressed content, so that later readers can tell whether the file has changed.
+func GetRawDataWithHash(source Source, filePath string) ([]common.Insight, string, error) {
+	file, err := OpenData(source, filePath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open file: %v", err)
+		return nil, "", err
 	}
 	defer file.Close()
 
 	// Read the file's content
 	content, err := io.ReadAll(file)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read file content: %v", err)
+		return nil, "", fmt.Errorf("failed to read file content: %v", err)
 	}
 
 	var data []common.Insight
 	if err := json.Unmarshal(content, &data); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal JSON: %v", err)
+		return nil, "", fmt.Errorf("failed to unmarshal JSON: %v", err)
 	}
 
-	return data, nil
+	hash := sha256.Sum256(content)
+	return data, hex.EncodeToString(hash[:]), nil
 }
@@ -0,0 +1,29 @@
+package file
+
+import "time"
+
+// WalkOptions selects the data files in a directory tree. The zero value selects every file that is not hidden,
+// at any depth, including links to files.
+type WalkOptions struct {
+	Include       []string      // glob patterns a file must match, every file when empty
+	Exclude       []string      // glob patterns of files and directories to leave out
+	Extensions    []string      // file extensions such as .json, matched case-insensitively, every file when empty
+	MaxDepth      int           // levels of directories to walk, where 1 is the files in the root, unlimited when 0
+	Symlinks      SymlinkPolicy // SymlinksFiles when empty
+	IncludeHidden bool          // include files and directories whose names start with a dot
+	ModifiedSince time.Time     // leave out files last modified before, when set
+}
+
+// SourceOptions configures the sources that are not on the local filesystem.
+type SourceOptions struct {
+	S3          S3Config
+	HTTPHeaders map[string]string // sent with every request to an HTTP source, such as Authorization
+}
+
+// S3Config configures access to S3-compatible object storage. Requests are unsigned when no access key is set.
+type S3Config struct {
+	Endpoint        string // such as http://localhost:9000; AWS when empty
+	Region          string // DefaultS3Region when empty
+	AccessKeyID     string
+	SecretAccessKey string
+}
@@ -0,0 +1,254 @@
+package file
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// S3Source reads data files from S3-compatible object storage, such as AWS S3 or MinIO, at locations of the form
+// s3://bucket/key. Requests are signed with AWS Signature Version 4. A custom endpoint is addressed path-style
+// (endpoint/bucket/key), as MinIO expects; AWS is addressed virtual-hosted style (bucket.s3.region.amazonaws.com).
+type S3Source struct {
+	Config S3Config
+	Client *http.Client
+	now    func() time.Time
+}
+
+func NewS3Source(config S3Config, client *http.Client) *S3Source {
+	if config.Region == "" {
+		config.Region = DefaultS3Region
+	}
+	return &S3Source{Config: config, Client: client, now: time.Now}
+}
+
+// listBucketResult is the response of a ListObjectsV2 request.
+type listBucketResult struct {
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+	Contents              []struct {
+		Key          string    `xml:"Key"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+}
+
+// s3Error is the body of an error response.
+type s3Error struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+// List returns the objects under the prefix of root, s3://bucket/prefix, that are selected by the options, in
+// key order. The prefix is a directory: s3://bucket/exports lists exports/ but not exports-old/. A root that names
+// an object is returned as is. Symbolic link policies do not apply to object storage.
+func (s *S3Source) List(root string, options WalkOptions) ([]string, error) {
+	bucket, prefix, err := parseS3Location(root)
+	if err != nil {
+		return nil, err
+	}
+
+	var locations []string
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		resp, err := s.do(bucket, "", query)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %v", root, err)
+		}
+		var result listBucketResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the listing of %s: %v", root, err)
+		}
+
+		for _, object := range result.Contents {
+			if object.Key == prefix && !strings.HasSuffix(prefix, "/") {
+				// The root names an object
+				return []string{root}, nil
+			}
+			relPath, ok := relativeKey(prefix, object.Key)
+			if ok && options.selectsObject(relPath, object.LastModified) {
+				locations = append(locations, fmt.Sprintf("%s://%s/%s", SchemeS3, bucket, object.Key))
+			}
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return locations, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// Open requests the object and returns its content.
+func (s *S3Source) Open(location string) (io.ReadCloser, error) {
+	bucket, key, err := parseS3Location(location)
+	if err != nil {
+		return nil, err
+	}
+	if key == "" || strings.HasSuffix(key, "/") {
+		return nil, fmt.Errorf("%s is not an object", location)
+	}
+	resp, err := s.do(bucket, key, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s: %v", location, err)
+	}
+	return resp.Body, nil
+}
+
+// do sends a signed GET request for the key in the bucket, or for the bucket itself if key is empty. Returns an
+// error if the request fails or the storage responds with an error status.
+func (s *S3Source) do(bucket, key string, query url.Values) (*http.Response, error) {
+	endpoint, err := s.objectURL(bucket, key, query)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodGet, endpoint.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	if s.Config.AccessKeyID != "" {
+		s.sign(req)
+	}
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		var s3Err s3Error
+		if xml.Unmarshal(body, &s3Err) == nil && s3Err.Code != "" {
+			return nil, fmt.Errorf("request failed with status %d: %s: %s", resp.StatusCode, s3Err.Code, s3Err.Message)
+		}
+		return nil, fmt.Errorf("request failed with status %d: %s", resp.StatusCode, body)
+	}
+	return resp, nil
+}
+
+// objectURL returns the URL of the key in the bucket: path-style on a custom endpoint, virtual-hosted style on AWS.
+func (s *S3Source) objectURL(bucket, key string, query url.Values) (*url.URL, error) {
+	var endpoint *url.URL
+	objectPath := "/" + key
+	if s.Config.Endpoint != "" {
+		var err error
+		if endpoint, err = url.Parse(strings.TrimSuffix(s.Config.Endpoint, "/")); err != nil || endpoint.Host == "" {
+			return nil, fmt.Errorf("invalid S3 endpoint %q", s.Config.Endpoint)
+		}
+		objectPath = endpoint.Path + "/" + bucket + objectPath
+	} else {
+		endpoint = &url.URL{Scheme: SchemeHTTPS, Host: fmt.Sprintf("%s.s3.%s.amazonaws.com", bucket, s.Config.Region)}
+	}
+	endpoint.Path = objectPath
+	endpoint.RawPath = uriEncode(objectPath, false)
+	endpoint.RawQuery = canonicalQuery(query)
+	return endpoint, nil
+}
+
+// sign adds the AWS Signature Version 4 headers to the request. Only GET requests without a body are sent, so the
+// payload hash is that of no content.
+func (s *S3Source) sign(req *http.Request) {
+	now := s.now().UTC()
+	amzDate := now.Format(s3TimeFormat)
+	scope := strings.Join([]string{now.Format(s3DateFormat), s.Config.Region, s3Service, "aws4_request"}, "/")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", emptyPayloadHash)
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalHeaders := fmt.Sprintf("host:%s\nx-amz-content-sha256:%s\nx-amz-date:%s\n", req.URL.Host, emptyPayloadHash, amzDate)
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders,
+		signedHeaders,
+		emptyPayloadHash,
+	}, "\n")
+	stringToSign := strings.Join([]string{s3Algorithm, amzDate, scope, sha256Hex(canonicalRequest)}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.Config.SecretAccessKey), now.Format(s3DateFormat))
+	for _, part := range []string{s.Config.Region, s3Service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3Algorithm, s.Config.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// parseS3Location splits s3://bucket/key into the bucket and the key.
+func parseS3Location(location string) (string, string, error) {
+	if scheme(location) == SchemeS3 {
+		bucket, key, _ := strings.Cut(location[len(SchemeS3+"://"):], "/")
+		if bucket != "" {
+			return bucket, key, nil
+		}
+	}
+	return "", "", fmt.Errorf("invalid S3 location %q, must be s3://bucket/prefix", location)
+}
+
+// relativeKey returns the key relative to the prefix, which is a directory, and whether the key is under it.
+func relativeKey(prefix, key string) (string, bool) {
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	if !strings.HasPrefix(key, prefix) || strings.HasSuffix(key, "/") || key == prefix {
+		return "", false
+	}
+	return strings.TrimPrefix(key, prefix), true
+}
+
+// canonicalQuery encodes the query sorted by name, with the encoding that Signature Version 4 requires.
+func canonicalQuery(query url.Values) string {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var pairs []string
+	for _, name := range names {
+		for _, value := range query[name] {
+			pairs = append(pairs, uriEncode(name, true)+"="+uriEncode(value, true))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// uriEncode percent-encodes every byte except the unreserved characters, and slashes unless encodeSlash is set.
+func uriEncode(value string, encodeSlash bool) string {
+	var encoded strings.Builder
+	for _, b := range []byte(value) {
+		switch {
+		case 'A' <= b && b <= 'Z', 'a' <= b && b <= 'z', '0' <= b && b <= '9', b == '-', b == '_', b == '.', b == '~':
+			encoded.WriteByte(b)
+		case b == '/' && !encodeSlash:
+			encoded.WriteByte(b)
+		default:
+			fmt.Fprintf(&encoded, "%%%02X", b)
+		}
+	}
+	return encoded.String()
+}
+
+func sha256Hex(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, value string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(value))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,119 @@
+package file
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Source lists and opens the data files at a location: a local directory, an S3 bucket prefix or an HTTP URL.
+type Source interface {
+	// List returns the locations of the data files under root that are selected by the options.
+	List(root string, options WalkOptions) ([]string, error)
+	// Open returns the content of the data file at location, as stored.
+	Open(location string) (io.ReadCloser, error)
+}
+
+// NewSource returns a source for every kind of location, chosen by the location's scheme: s3://bucket/prefix from
+// S3-compatible object storage, http:// and https:// URLs over HTTP, and anything else from the local filesystem.
+func NewSource(options SourceOptions) Source {
+	client := &http.Client{Timeout: RequestTimeout}
+	return schemeSource{
+		local: LocalSource{},
+		s3:    NewS3Source(options.S3, client),
+		http:  NewHTTPSource(options.HTTPHeaders, client),
+	}
+}
+
+// schemeSource passes every call on to the source for the location's scheme.
+type schemeSource struct {
+	local Source
+	s3    Source
+	http  Source
+}
+
+func (s schemeSource) List(root string, options WalkOptions) ([]string, error) {
+	return s.sourceFor(root).List(root, options)
+}
+
+func (s schemeSource) Open(location string) (io.ReadCloser, error) {
+	return s.sourceFor(location).Open(location)
+}
+
+func (s schemeSource) sourceFor(location string) Source {
+	switch scheme(location) {
+	case SchemeS3:
+		return s.s3
+	case SchemeHTTP, SchemeHTTPS:
+		return s.http
+	default:
+		return s.local
+	}
+}
+
+// IsRemote reports whether the location is read from object storage or over HTTP rather than from the local
+// filesystem.
+func IsRemote(location string) bool {
+	switch scheme(location) {
+	case SchemeS3, SchemeHTTP, SchemeHTTPS:
+		return true
+	}
+	return false
+}
+
+// scheme returns the lower-case URL scheme of the location, or an empty string for a local path.
+func scheme(location string) string {
+	if i := strings.Index(location, "://"); i > 0 {
+		return strings.ToLower(location[:i])
+	}
+	return ""
+}
+
+// BaseName returns the last element of the location's path, without the query of a URL, such as shop.json.gz for
+// s3://exports/2024/shop.json.gz.
+func BaseName(location string) string {
+	if IsRemote(location) {
+		if parsed, err := url.Parse(location); err == nil {
+			return path.Base(parsed.Path)
+		}
+	}
+	return filepath.Base(location)
+}
+
+// Name returns the name of the data file at location: its base name without the compression and file
+// extensions, such as shop for s3://exports/2024/shop.json.gz.
+func Name(location string) string {
+	base := strings.TrimSuffix(BaseName(location), GzipExtension)
+	return strings.TrimSuffix(base, path.Ext(base))
+}
+
+// OpenData opens the data file at location through the source, decompressing it if it is gzip-compressed.
+// Compressed files are recognised by their content, whatever their name.
+func OpenData(source Source, location string) (io.ReadCloser, error) {
+	content, err := source.Open(location)
+	if err != nil {
+		return nil, err
+	}
+	buffered := bufio.NewReader(content)
+	if magic, _ := buffered.Peek(2); len(magic) < 2 || magic[0] != 0x1f || magic[1] != 0x8b {
+		return readCloser{Reader: buffered, Closer: content}, nil
+	}
+	decompressed, err := gzip.NewReader(buffered)
+	if err != nil {
+		content.Close()
+		return nil, fmt.Errorf("failed to decompress file: %v", err)
+	}
+	return readCloser{Reader: decompressed, Closer: content}, nil
+}
+
+// readCloser reads from Reader and closes Closer, the underlying content of a buffered or decompressing reader.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,98 @@
+package file
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func gzipped(t *testing.T, content string) string {
+	t.Helper()
+	var buffer bytes.Buffer
+	writer := gzip.NewWriter(&buffer)
+	if _, err := io.WriteString(writer, content); err != nil {
+		t.Fatalf("failed to compress: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("failed to compress: %v", err)
+	}
+	return buffer.String()
+}
+
+func TestOpenDataDecompressesGzip(t *testing.T) {
+	const data = `{"rows":[{"Sessions":3}]}`
+	files := map[string]string{
+		"/shop.json.gz":   gzipped(t, data), // compressed, named as such
+		"/shop.json":      gzipped(t, data), // compressed, recognised by its content
+		"/plain.json":     data,
+		"/plain.json.gz":  data, // named as compressed, but stored plain
+		"/empty.json":     "",
+		"/one-byte.json":  "\x1f",
+		"/broken.json.gz": "\x1f\x8bnot gzip",
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		// Served as stored, without a Content-Encoding that the client would decode
+		w.Header().Set("Content-Type", "application/octet-stream")
+		io.WriteString(w, content)
+	}))
+	defer server.Close()
+	source := NewSource(SourceOptions{})
+
+	tests := []struct {
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{path: "/shop.json.gz", want: data},
+		{path: "/shop.json", want: data},
+		{path: "/plain.json", want: data},
+		{path: "/plain.json.gz", want: data},
+		{path: "/empty.json", want: ""},
+		{path: "/one-byte.json", want: "\x1f"},
+		{path: "/broken.json.gz", wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			content, err := OpenData(source, server.URL+test.path)
+			if test.wantErr {
+				if err == nil {
+					t.Fatal("invalid gzip content was opened")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to open: %v", err)
+			}
+			defer content.Close()
+			got, err := io.ReadAll(content)
+			if err != nil {
+				t.Fatalf("failed to read: %v", err)
+			}
+			if string(got) != test.want {
+				t.Errorf("got content %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestName(t *testing.T) {
+	tests := map[string]string{
+		"data/shop.json":                              "shop",
+		"s3://exports/2024/shop.json.gz":              "shop",
+		"https://example.com/exports/shop.json?day=1": "shop",
+		"shop": "shop",
+	}
+	for location, want := range tests {
+		if got := Name(location); got != want {
+			t.Errorf("Name(%q) = %q, want %q", location, got, want)
+		}
+	}
+}
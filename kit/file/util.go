@@ -2,25 +2,296 @@ package file
 
 import (
 	"fmt"
+	"io"
+	"log"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
+	"time"
 )
 
-// FilePathWalkDir walks through the directory tree rooted at 'root' and returns a slice of file paths. It skips
-// directories and returns an error if any issue is encountered during the walk.
-func FilePathWalkDir(root string) ([]string, error) {
-	var files []string
-	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
-		if info == nil {
-			return fmt.Errorf("nil FileInfo for path: %s", path)
-		}
-		if !info.IsDir() {
-			files = append(files, path)
-		}
-		return nil
-	})
+// LocalSource reads data files from the local filesystem.
+type LocalSource struct{}
+
+// List walks the directory tree rooted at root with FilePathWalkDir.
+func (LocalSource) List(root string, options WalkOptions) ([]string, error) {
+	return FilePathWalkDir(root, options)
+}
+
+func (LocalSource) Open(location string) (io.ReadCloser, error) {
+	content, err := os.Open(location)
 	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %v", err)
+	}
+	return content, nil
+}
+
+// FilePathWalkDir walks through the directory tree rooted at 'root' and returns the paths of the files selected by
+// the options, in lexical order. Entries that cannot be read are logged and skipped; an error is only returned if
+// the root itself cannot be read. A root that is a file is returned as is.
+func FilePathWalkDir(root string, options WalkOptions) ([]string, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, fmt.Errorf("error walking the directory tree: %v", err)
+	}
+	if !info.IsDir() {
+		return []string{root}, nil
+	}
+
+	w := walker{options: options, visited: map[string]bool{}}
+	if w.options.Symlinks == "" {
+		w.options.Symlinks = SymlinksFiles
+	}
+	if realRoot, err := filepath.EvalSymlinks(root); err == nil {
+		w.visited[realRoot] = true
+	}
+	if err := w.walk(root, "", 1); err != nil {
 		return nil, fmt.Errorf("error walking the directory tree: %v", err)
 	}
-	return files, nil
+	return w.files, nil
+}
+
+// walker collects the selected files of a directory tree. visited holds the real paths of the directories walked,
+// so that links that loop back are not followed again.
+type walker struct {
+	options WalkOptions
+	visited map[string]bool
+	files   []string
+}
+
+// walk adds the selected files in dir and its subdirectories. rel is the slash-separated path of dir relative to
+// the root and depth the level of its entries.
+func (w *walker) walk(dir, rel string, depth int) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		entryPath := filepath.Join(dir, entry.Name())
+		relPath := path.Join(rel, entry.Name())
+		if w.options.hides(entry.Name()) {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			log.Printf("Skipping %s: %v", entryPath, err)
+			continue
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			if w.options.Symlinks == SymlinksSkip {
+				continue
+			}
+			if info, err = os.Stat(entryPath); err != nil {
+				log.Printf("Skipping %s: %v", entryPath, err)
+				continue
+			}
+			if info.IsDir() && w.options.Symlinks != SymlinksFollow {
+				continue
+			}
+		}
+
+		if info.IsDir() {
+			if matchesAnyPath(w.options.Exclude, relPath) || !w.options.descends(depth) {
+				continue
+			}
+			realPath, err := filepath.EvalSymlinks(entryPath)
+			if err != nil {
+				log.Printf("Skipping %s: %v", entryPath, err)
+				continue
+			}
+			if w.visited[realPath] {
+				continue
+			}
+			w.visited[realPath] = true
+			if err := w.walk(entryPath, relPath, depth+1); err != nil {
+				log.Printf("Skipping %s: %v", entryPath, err)
+			}
+			continue
+		}
+
+		if info.Mode().IsRegular() && w.options.selects(relPath, info.ModTime()) {
+			w.files = append(w.files, entryPath)
+		}
+	}
+	return nil
+}
+
+// selects reports whether the file at the slash-separated relative path passes the filters.
+func (o WalkOptions) selects(relPath string, modTime time.Time) bool {
+	if len(o.Extensions) > 0 && !hasExtension(o.Extensions, relPath) {
+		return false
+	}
+	if len(o.Include) > 0 && !matchesAnyPath(o.Include, relPath) {
+		return false
+	}
+	if matchesAnyPath(o.Exclude, relPath) {
+		return false
+	}
+	return o.ModifiedSince.IsZero() || !modTime.Before(o.ModifiedSince)
+}
+
+// selectsObject reports whether the object at the slash-separated path relative to the listed prefix passes the
+// filters, for sources without directories: its parent directories must not be hidden, excluded or too deep.
+func (o WalkOptions) selectsObject(relPath string, modTime time.Time) bool {
+	segments := strings.Split(relPath, "/")
+	if !o.descends(len(segments) - 1) {
+		return false
+	}
+	for i, segment := range segments {
+		if o.hides(segment) {
+			return false
+		}
+		if i < len(segments)-1 && matchesAnyPath(o.Exclude, strings.Join(segments[:i+1], "/")) {
+			return false
+		}
+	}
+	return o.selects(relPath, modTime)
+}
+
+// hides reports whether a file or directory is left out because its name starts with a dot.
+func (o WalkOptions) hides(name string) bool {
+	return !o.IncludeHidden && strings.HasPrefix(name, ".")
+}
+
+// descends reports whether the walk goes into the directories at depth, where 1 is the directories in the root.
+func (o WalkOptions) descends(depth int) bool {
+	return o.MaxDepth == 0 || depth < o.MaxDepth
+}
+
+// hasExtension reports whether the file has one of the extensions. The extension of a gzip-compressed file is
+// the one before .gz, unless .gz is one of the extensions.
+func hasExtension(extensions []string, relPath string) bool {
+	ext := path.Ext(relPath)
+	for _, extension := range extensions {
+		if strings.EqualFold(ext, extension) {
+			return true
+		}
+	}
+	if strings.EqualFold(ext, GzipExtension) {
+		return hasExtension(extensions, strings.TrimSuffix(relPath, ext))
+	}
+	return false
+}
+
+// matchesAnyPath reports whether the slash-separated path relative to the walk's root matches any of the glob
+// patterns. A pattern without a slash matches the base name at any depth; a pattern with a slash matches the whole
+// relative path, where a ** segment matches any number of directories.
+func matchesAnyPath(patterns []string, relPath string) bool {
+	for _, pattern := range patterns {
+		if !strings.Contains(pattern, "/") {
+			if matched, _ := path.Match(pattern, path.Base(relPath)); matched {
+				return true
+			}
+			continue
+		}
+		if matchSegments(strings.Split(pattern, "/"), strings.Split(relPath, "/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchSegments matches the path segments against the pattern segments, where ** matches zero or more segments.
+func matchSegments(pattern, segments []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(segments); i++ {
+				if matchSegments(pattern[1:], segments[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(segments) == 0 {
+			return false
+		}
+		if matched, _ := path.Match(pattern[0], segments[0]); !matched {
+			return false
+		}
+		pattern, segments = pattern[1:], segments[1:]
+	}
+	return len(segments) == 0
+}
+
+// MatchesAny reports whether the base name of fileSource matches any of the glob patterns. An empty pattern list
+// matches every file.
+func MatchesAny(patterns []string, fileSource string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	name := filepath.Base(fileSource)
+	for _, pattern := range patterns {
+		if matched, _ := filepath.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// ParsePatterns splits a comma-separated list of glob patterns. Returns an error if a pattern is malformed.
+func ParsePatterns(value string) ([]string, error) {
+	var patterns []string
+	for _, pattern := range strings.Split(value, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern == "" {
+			continue
+		}
+		for _, segment := range strings.Split(pattern, "/") {
+			if _, err := path.Match(segment, ""); err != nil {
+				return nil, fmt.Errorf("invalid glob pattern %q: %v", pattern, err)
+			}
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, nil
+}
+
+// ParseExtensions splits a comma-separated list of file extensions, adding the leading dot where it is missing.
+// AllExtensions selects every file and returns no extensions.
+func ParseExtensions(value string) []string {
+	var extensions []string
+	for _, extension := range strings.Split(value, ",") {
+		if extension = strings.TrimSpace(extension); extension == "" {
+			continue
+		}
+		if extension == AllExtensions {
+			return nil
+		}
+		if !strings.HasPrefix(extension, ".") {
+			extension = "." + extension
+		}
+		extensions = append(extensions, extension)
+	}
+	return extensions
+}
+
+// ParseSymlinkPolicy parses a symlink policy, which is SymlinksFiles when empty.
+func ParseSymlinkPolicy(value string) (SymlinkPolicy, error) {
+	switch policy := SymlinkPolicy(value); policy {
+	case "":
+		return SymlinksFiles, nil
+	case SymlinksSkip, SymlinksFiles, SymlinksFollow:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown symlink policy %q, must be skip, files or follow", value)
+	}
+}
+
+// ParseModifiedSince parses a modified-since filter: a duration such as 24h before now, a date such as
+// 2024-08-01, or an RFC 3339 time. An empty value returns the zero time, which disables the filter.
+func ParseModifiedSince(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if duration, err := time.ParseDuration(value); err == nil && duration >= 0 {
+		return now.Add(-duration), nil
+	}
+	if date, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return date, nil
+	}
+	if moment, err := time.Parse(time.RFC3339, value); err == nil {
+		return moment, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid modified-since value %q: must be a duration such as 24h, a date such as 2024-08-01 or an RFC 3339 time", value)
 }
@@ -0,0 +1,10 @@
+package ledger
+
+const DefaultPath = "ledger.json"
+
+type Status string
+
+const (
+	StatusDelivered      Status = "delivered"
+	StatusDeliveryFailed Status = "delivery_failed" // the insights were stored, but a channel failed
+)
@@ -0,0 +1,105 @@
+package ledger
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// Ledger records the data files that have been processed, by content hash, so that a file is never reported twice,
+// also across restarts. It is kept in a JSON file and is safe for concurrent use.
+type Ledger struct {
+	Path    string
+	mu      sync.Mutex
+	entries map[string]Entry
+}
+
+// Load reads the ledger file. A missing file is an empty ledger.
+func Load(path string) (*Ledger, error) {
+	ledger := &Ledger{Path: path, entries: map[string]Entry{}}
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return ledger, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ledger: %v", err)
+	}
+	var file File
+	if err := json.Unmarshal(content, &file); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal ledger: %v", err)
+	}
+	for _, entry := range file.Entries {
+		ledger.entries[entry.Path] = entry
+	}
+	return ledger, nil
+}
+
+// Processed reports whether the file was processed and delivered with the given content hash. A file whose
+// delivery failed is not processed, so that it is delivered again.
+func (l *Ledger) Processed(path, hash string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	entry, ok := l.entries[path]
+	return ok && entry.Hash == hash && entry.Status == StatusDelivered
+}
+
+// Delivered returns the email recipients that received the file with the given content hash in a delivery that
+// failed, so that delivering it again skips them.
+func (l *Ledger) Delivered(path, hash string) []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	entry, ok := l.entries[path]
+	if !ok || entry.Hash != hash || entry.Status != StatusDeliveryFailed {
+		return nil
+	}
+	return entry.Delivered
+}
+
+// Record adds the entry, replacing the one of an earlier version of the file, and saves the ledger.
+func (l *Ledger) Record(entry Entry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries[entry.Path] = entry
+	return l.save()
+}
+
+// save writes the ledger to a temporary file first, so that an interrupted write never corrupts it.
+func (l *Ledger) save() error {
+	file := File{Entries: make([]Entry, 0, len(l.entries))}
+	for _, entry := range l.entries {
+		file.Entries = append(file.Entries, entry)
+	}
+	sort.Slice(file.Entries, func(i, j int) bool { return file.Entries[i].Path < file.Entries[j].Path })
+
+	if dir := filepath.Dir(l.Path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("failed to create ledger directory: %v", err)
+		}
+	}
+	content, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal ledger: %v", err)
+	}
+	if err := os.WriteFile(l.Path+".tmp", content, 0o644); err != nil {
+		return fmt.Errorf("failed to write ledger: %v", err)
+	}
+	if err := os.Rename(l.Path+".tmp", l.Path); err != nil {
+		return fmt.Errorf("failed to write ledger: %v", err)
+	}
+	return nil
+}
+
+// Hash returns the hex-encoded SHA-256 hash of a file's content.
+func Hash(content io.Reader) (string, error) {
+	hash := sha256.New()
+	if _, err := io.Copy(hash, content); err != nil {
+		return "", fmt.Errorf("failed to hash file: %v", err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,18 @@
+package ledger
+
+import "time"
+
+// Entry records the processing of one version of a data file.
+type Entry struct {
+	Path        string    `json:"path"`
+	Hash        string    `json:"hash"` // SHA-256 of the file content
+	ProcessedAt time.Time `json:"processed_at"`
+	Model       string    `json:"model,omitempty"` // the model, or "rules", that generated the insights
+	Status      Status    `json:"status,omitempty"`
+	Error       string    `json:"error,omitempty"`     // the delivery error, if a channel failed
+	Delivered   []string  `json:"delivered,omitempty"` // the email recipients that received the file, if a channel failed
+}
+
+type File struct {
+	Entries []Entry `json:"entries"`
+}
@@ -11,17 +11,7 @@ func AggregateMetricsByBreakdown(data []common.Insight, breakdown common.Breakdo
 
 	// Group data based on the breakdown (Country, DeviceCategory, LandingPage, SessionMedium)
 	for _, insight := range data {
-		var key string
-		switch breakdown {
-		case common.COUNTRY:
-			key = insight.Country
-		case common.DEVICE:
-			key = insight.DeviceCategory
-		case common.PAGE:
-			key = insight.LandingPage
-		case common.MEDIUM:
-			key = insight.SessionMedium
-		}
+		key := DimensionValue(insight, breakdown)
 		metricsMap[key] = append(metricsMap[key], insight)
 	}
 
@@ -0,0 +1,41 @@
+package metrics
+
+import (
+	"data-insights/kit/common"
+	"strings"
+)
+
+// DimensionValue returns the value of the given breakdown dimension for a single data point.
+func DimensionValue(insight common.Insight, breakdown common.Breakdown) string {
+	switch breakdown {
+	case common.COUNTRY:
+		return insight.Country
+	case common.DEVICE:
+		return insight.DeviceCategory
+	case common.PAGE:
+		return insight.LandingPage
+	case common.MEDIUM:
+		return insight.SessionMedium
+	}
+	return ""
+}
+
+// FilterByDimension returns the data points whose value for the given breakdown equals value, ignoring case.
+func FilterByDimension(data []common.Insight, breakdown common.Breakdown, value string) []common.Insight {
+	var filtered []common.Insight
+	for _, insight := range data {
+		if strings.EqualFold(DimensionValue(insight, breakdown), value) {
+			filtered = append(filtered, insight)
+		}
+	}
+	return filtered
+}
+
+// IsValidBreakdown reports whether breakdown is one of the supported dimensions.
+func IsValidBreakdown(breakdown common.Breakdown) bool {
+	switch breakdown {
+	case common.COUNTRY, common.DEVICE, common.PAGE, common.MEDIUM:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,71 @@
+package metrics
+
+import (
+	"data-insights/kit/common"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// dateLayouts lists the accepted date formats, the compact Google Analytics format first.
+var dateLayouts = []string{common.DateLayout, time.DateOnly}
+
+// ParseDate parses a date in either the Google Analytics (20060102) or the ISO (2006-01-02) format.
+func ParseDate(value string) (time.Time, error) {
+	for _, layout := range dateLayouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date: %s", value)
+}
+
+// FilterByDateRange returns the data points dated between from and to, both inclusive. A zero bound is open.
+// Data points without a parsable date are excluded.
+func FilterByDateRange(data []common.Insight, from, to time.Time) []common.Insight {
+	var filtered []common.Insight
+	for _, insight := range data {
+		date, err := ParseDate(insight.Date)
+		if err != nil {
+			continue
+		}
+		if (!from.IsZero() && date.Before(from)) || (!to.IsZero() && date.After(to)) {
+			continue
+		}
+		filtered = append(filtered, insight)
+	}
+	return filtered
+}
+
+// CalculateDailyMetrics groups the data by date and calculates the overall metrics for every day, oldest first.
+// Data points without a parsable date are skipped.
+func CalculateDailyMetrics(data []common.Insight) []common.DailyMetrics {
+	byDate := make(map[time.Time][]common.Insight)
+	for _, insight := range data {
+		date, err := ParseDate(insight.Date)
+		if err != nil {
+			continue
+		}
+		byDate[date] = append(byDate[date], insight)
+	}
+
+	dates := make([]time.Time, 0, len(byDate))
+	for date := range byDate {
+		dates = append(dates, date)
+	}
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+
+	daily := make([]common.DailyMetrics, 0, len(dates))
+	for _, date := range dates {
+		var sessions int
+		for _, insight := range byDate[date] {
+			sessions += insight.Sessions
+		}
+		daily = append(daily, common.DailyMetrics{
+			Date:           date.Format(time.DateOnly),
+			TotalSessions:  sessions,
+			OverallMetrics: CalculateOverallMetrics(byDate[date]),
+		})
+	}
+	return daily
+}
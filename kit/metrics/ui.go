@@ -10,23 +10,29 @@ const (
 // CalculateKeyMetrics calculates and returns key metrics for the dataset,
 // including overall metrics and top/bottom breakdowns by country, device, page, and medium.
 func CalculateKeyMetrics(data []common.Insight) common.UserMetrics {
+	return CalculateKeyMetricsWithThreshold(data, thresholdDataPointNumber)
+}
+
+// CalculateKeyMetricsWithThreshold calculates the key metrics like CalculateKeyMetrics, leaving out groups with
+// fewer than minDataPoints data points from the breakdowns.
+func CalculateKeyMetricsWithThreshold(data []common.Insight, minDataPoints int) common.UserMetrics {
 	// Calculate overall metrics
 	overallMetrics := CalculateOverallMetrics(data)
 
 	// Aggregate and sort metrics by country
-	metricsByCountry := AggregateMetricsByBreakdown(data, common.COUNTRY, thresholdDataPointNumber)
+	metricsByCountry := AggregateMetricsByBreakdown(data, common.COUNTRY, minDataPoints)
 	metricsByCountry.SortByField(common.AVGENGAGEMENTRATE, common.DESC)
 
 	// Aggregate and sort metrics by device category
-	metricsByDevices := AggregateMetricsByBreakdown(data, common.DEVICE, thresholdDataPointNumber)
+	metricsByDevices := AggregateMetricsByBreakdown(data, common.DEVICE, minDataPoints)
 	metricsByDevices.SortByField(common.BOUNCERATE, common.DESC)
 
 	// Aggregate and sort metrics by page
-	metricsByPages := AggregateMetricsByBreakdown(data, common.PAGE, thresholdDataPointNumber)
+	metricsByPages := AggregateMetricsByBreakdown(data, common.PAGE, minDataPoints)
 	metricsByPages.SortByField(common.TOTALSESSIONS, common.DESC)
 
 	// Aggregate and sort metrics by session medium
-	metricsByMedium := AggregateMetricsByBreakdown(data, common.MEDIUM, thresholdDataPointNumber)
+	metricsByMedium := AggregateMetricsByBreakdown(data, common.MEDIUM, minDataPoints)
 	metricsByMedium.SortByField(common.AVGSESSIONDURATION, common.DESC)
 
 	// Return the aggregated user metrics with top and bottom elements for each breakdown
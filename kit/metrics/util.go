@@ -3,6 +3,9 @@ package metrics
 import "strconv"
 
 func GetTopElements[T any](slice []T, numberOfElements int) []T {
+	if numberOfElements > len(slice) {
+		numberOfElements = len(slice)
+	}
 	return slice[:numberOfElements]
 }
 
@@ -10,7 +13,8 @@ func GetBottomElements[T any](slice []T, numberOfElements int) []T {
 	if numberOfElements > len(slice) {
 		numberOfElements = len(slice)
 	}
-	bottomElements := slice[len(slice)-numberOfElements:]
+	// Copy the elements, since they may overlap with the top elements of the same slice
+	bottomElements := append([]T(nil), slice[len(slice)-numberOfElements:]...)
 
 	// Reverse the order of bottomElements
 	for i, j := 0, len(bottomElements)-1; i < j; i, j = i+1, j-1 {
@@ -0,0 +1,102 @@
+package metrics
+
+import (
+	"data-insights/kit/common"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var numberPattern = regexp.MustCompile(`-?\d+(?:\.\d+)?`)
+
+// ParseNumber returns the first number in a formatted value such as "64.12%" or "1,204 sessions".
+func ParseNumber(value string) (float64, bool) {
+	match := numberPattern.FindString(strings.ReplaceAll(value, ",", ""))
+	if match == "" {
+		return 0, false
+	}
+	number, err := strconv.ParseFloat(match, 64)
+	if err != nil {
+		return 0, false
+	}
+	return number, true
+}
+
+// ValueMatches reports whether got equals expected within a relative tolerance, allowing for rounding to two
+// decimals. Rates are accepted both as a fraction and as a percentage.
+func ValueMatches(got, expected float64, isRate bool, tolerance float64) bool {
+	candidates := []float64{expected}
+	if isRate {
+		candidates = append(candidates, expected*100)
+	}
+	for _, candidate := range candidates {
+		if math.Abs(got-candidate) <= tolerance*math.Abs(candidate)+0.005 {
+			return true
+		}
+	}
+	return false
+}
+
+// SectionMetrics returns the computed metrics list behind an aggregated section.
+func SectionMetrics(userMetrics common.UserMetrics, section common.Section) (common.AggregatedMetricsList, bool) {
+	switch section {
+	case common.TOPCOUNTRIESSECTION:
+		return userMetrics.Top5CountriesWithHighestEngagementRate, true
+	case common.BOTTOMCOUNTRIESSECTION:
+		return userMetrics.Top5CountriesWithLowestEngagementRate, true
+	case common.BOUNCERATESSECTION:
+		return userMetrics.BounceRatesByDevices, true
+	case common.TOPPAGESSECTION:
+		return userMetrics.Top5PagesWithHighestNoOfSessions, true
+	case common.BOTTOMPAGESSECTION:
+		return userMetrics.Top5PagesWithLowestNoOfSessions, true
+	case common.SESSIONDURATIONSSECTION:
+		return userMetrics.AverageSessionDurationsByDevices, true
+	}
+	return nil, false
+}
+
+// LookupValue returns the computed value referenced by a section, a row name and a metric, all given by their
+// JSON names as used in UserMetricsWithInsights. The row name is ignored for the overall section.
+func LookupValue(userMetrics common.UserMetrics, section common.Section, name, metric string) (value float64, isRate bool, ok bool) {
+	if section == common.OVERALLSECTION {
+		overall := userMetrics.OverallMetrics
+		switch metric {
+		case "overall_engagement_rate":
+			return overall.OverallEngagementRate, true, true
+		case "average_session_duration":
+			return overall.AverageSessionDuration, false, true
+		case "bounce_rate":
+			return overall.BounceRate, false, true
+		case "pages_per_session":
+			return overall.PagesPerSession, false, true
+		case "new_user_percentage":
+			return overall.NewUserPercentage, false, true
+		case "session_per_user":
+			return overall.SessionPerUser, false, true
+		}
+		return 0, false, false
+	}
+
+	list, ok := SectionMetrics(userMetrics, section)
+	if !ok {
+		return 0, false, false
+	}
+	for _, row := range list {
+		if !strings.EqualFold(row.Name, name) {
+			continue
+		}
+		switch metric {
+		case "average_engagement_rate":
+			return row.AverageEngagementRate, true, true
+		case "bounce_rate":
+			return row.BounceRate, false, true
+		case "total_sessions":
+			return float64(row.TotalSessions), false, true
+		case "average_session_duration":
+			return row.AverageSessionDuration, false, true
+		}
+	}
+	return 0, false, false
+}
@@ -0,0 +1,82 @@
+package notify
+
+import (
+	"data-insights/kit/common"
+	"data-insights/kit/file"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+)
+
+// LoadChannels reads and validates a channels configuration file.
+func LoadChannels(path string) (ChannelsConfig, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return ChannelsConfig{}, fmt.Errorf("failed to read channels file: %v", err)
+	}
+	var config ChannelsConfig
+	if err := json.Unmarshal(content, &config); err != nil {
+		return ChannelsConfig{}, fmt.Errorf("failed to unmarshal channels file: %v", err)
+	}
+	if err := config.Validate(); err != nil {
+		return ChannelsConfig{}, err
+	}
+	return config, nil
+}
+
+// Validate checks every channel. An empty list is valid: reports are then only written to disk.
+func (c ChannelsConfig) Validate() error {
+	for i, channel := range c.Channels {
+		if err := channel.Validate(); err != nil {
+			return fmt.Errorf("channel %d (%s): %v", i+1, channel.Type, err)
+		}
+	}
+	return nil
+}
+
+// Validate checks that the channel has a known type, that a webhook channel has an absolute http(s) URL, and that
+// all sections and file patterns are valid.
+func (c Channel) Validate() error {
+	switch c.Type {
+	case ChannelEmail:
+	case ChannelSlack, ChannelTeams, ChannelWebhook:
+		if err := validateURL(c.URL); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown type %q", c.Type)
+	}
+	for _, section := range c.Sections {
+		if !common.IsValidSection(section) {
+			return fmt.Errorf("unknown section %q", section)
+		}
+	}
+	for _, pattern := range c.Files {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid file pattern %q: %v", pattern, err)
+		}
+	}
+	return nil
+}
+
+// receives reports whether the channel's file patterns match the data file's base name. A channel without
+// patterns receives every file.
+func (c Channel) receives(fileSource string) bool {
+	return file.MatchesAny(c.Files, fileSource)
+}
+
+func validateURL(rawURL string) error {
+	if rawURL == "" {
+		return fmt.Errorf("url is required")
+	}
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid url: %v", err)
+	}
+	if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("url %q must be an absolute http or https URL", rawURL)
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+package notify
+
+type ChannelType string
+
+const (
+	ChannelEmail   ChannelType = "email"
+	ChannelSlack   ChannelType = "slack"   // Slack incoming webhook, rendered with Block Kit
+	ChannelTeams   ChannelType = "teams"   // Microsoft Teams incoming webhook, rendered as an Adaptive Card
+	ChannelWebhook ChannelType = "webhook" // generic webhook receiving the report as JSON
+)
+
+const (
+	ReportTitle = "Website Insights Report"
+
+	SlackMaxBlocks      = 50
+	SlackMaxTextLength  = 3000
+	SlackMaxFields      = 10
+	SlackMaxFieldLength = 2000
+
+	AdaptiveCardContentType = "application/vnd.microsoft.card.adaptive"
+	AdaptiveCardSchema      = "http://adaptivecards.io/schemas/adaptive-card.json"
+	AdaptiveCardVersion     = "1.4"
+)
@@ -0,0 +1,218 @@
+package notify
+
+import (
+	"data-insights/kit/chart"
+	"data-insights/kit/common"
+	"data-insights/kit/email"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var nonAnchorChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+type EmailNotifier struct {
+	Service    email.EmailService
+	Renderer   email.Renderer
+	Recipients email.RecipientsConfig
+}
+
+func NewEmailNotifier(service email.EmailService, renderer email.Renderer, recipients email.RecipientsConfig) *EmailNotifier {
+	return &EmailNotifier{Service: service, Renderer: renderer, Recipients: recipients}
+}
+
+// RecipientsError reports that the email could not be sent to some recipients. Delivered are the recipients that
+// received it, also in earlier attempts, so that a retry can skip them.
+type RecipientsError struct {
+	Delivered []string
+	Err       error
+}
+
+func (e *RecipientsError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RecipientsError) Unwrap() error {
+	return e.Err
+}
+
+// Notify renders and sends a personalised email to every recipient of the data file, except those in the
+// notification's Delivered. Recipients without their own sections receive the channel's sections. A failing
+// recipient does not stop the others; their errors are returned together in a RecipientsError.
+func (e *EmailNotifier) Notify(notification Notification) error {
+	deliveries, err := e.Recipients.Deliveries(notification.SourceFile)
+	if err != nil {
+		return fmt.Errorf("error resolving recipients: %v", err)
+	}
+	if len(deliveries) == 0 {
+		log.Printf("No recipients for the file %s, skipping email", notification.SourceFile)
+		return nil
+	}
+
+	delivered := slices.Clone(notification.Delivered)
+	var errs []error
+	for _, delivery := range deliveries {
+		if slices.Contains(notification.Delivered, delivery.To) {
+			log.Printf("Skipping %s, insights from the file %s have already been sent to them", delivery.To, notification.SourceFile)
+			continue
+		}
+		sections := delivery.Sections
+		if len(sections) == 0 {
+			sections = notification.Sections
+		}
+
+		// Attach the charts of the recipient's sections inline
+		inline, chartURLs := inlineCharts(notification.Charts, sections, "")
+
+		// Render the email body with the recipient's greeting and sections
+		body, err := e.Renderer.Render(common.EmailData{
+			RecipientName:           delivery.Name,
+			UserMetricsWithInsights: notification.Insights,
+			ToolCalls:               notification.ToolCalls,
+			Sections:                sections,
+			Charts:                  chartURLs,
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error rendering email template for %s: %v", delivery.To, err))
+			continue
+		}
+
+		// Send the email
+		err = e.Service.SendEmail(email.Message{
+			To:          []string{delivery.To},
+			Cc:          delivery.Cc,
+			Bcc:         delivery.Bcc,
+			Subject:     email.SubjectName,
+			Body:        body,
+			Inline:      inline,
+			Attachments: notification.Attachments,
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error sending email to %s: %v", delivery.To, err))
+			continue
+		}
+		delivered = append(delivered, delivery.To)
+		log.Printf("Email sent successfully, insights from the file %s has been sent to %s", notification.SourceFile, delivery.To)
+	}
+	if len(errs) > 0 {
+		return &RecipientsError{Delivered: delivered, Err: errors.Join(errs...)}
+	}
+	return nil
+}
+
+// NotifyDigest renders and sends one digest email to every recipient of any of the digest's data files. Each
+// recipient's digest contains only the files they receive, with their own sections or the channel's. A failing
+// recipient does not stop the others; their errors are returned together.
+func (e *EmailNotifier) NotifyDigest(digest Digest) error {
+	var recipients []string
+	deliveries := make(map[string]email.Delivery)
+	files := make(map[string][]Notification)
+	for _, notification := range digest.Notifications {
+		fileDeliveries, err := e.Recipients.Deliveries(notification.SourceFile)
+		if err != nil {
+			return fmt.Errorf("error resolving recipients: %v", err)
+		}
+		for _, delivery := range fileDeliveries {
+			if _, seen := deliveries[delivery.To]; !seen {
+				recipients = append(recipients, delivery.To)
+				deliveries[delivery.To] = delivery
+			}
+			files[delivery.To] = append(files[delivery.To], notification)
+		}
+	}
+	if len(recipients) == 0 {
+		log.Printf("No recipients for the digest, skipping email")
+		return nil
+	}
+
+	var errs []error
+	for _, to := range recipients {
+		delivery := deliveries[to]
+		var inline, attachments []email.Attachment
+		var reports []common.DigestReport
+		for _, notification := range files[to] {
+			sections := delivery.Sections
+			if len(sections) == 0 {
+				sections = notification.Sections
+			}
+
+			// Prefix the charts' content IDs with the report's anchor, since every file has the same charts
+			anchor := digestAnchor(notification.Property.Name, len(reports))
+			fileInline, chartURLs := inlineCharts(notification.Charts, sections, anchor)
+			inline = append(inline, fileInline...)
+			attachments = append(attachments, notification.Attachments...)
+			reports = append(reports, common.DigestReport{
+				Property: notification.Property,
+				Anchor:   anchor,
+				EmailData: common.EmailData{
+					UserMetricsWithInsights: notification.Insights,
+					ToolCalls:               notification.ToolCalls,
+					Sections:                sections,
+					Charts:                  chartURLs,
+				},
+			})
+		}
+
+		body, err := e.Renderer.Render(common.EmailData{
+			RecipientName: delivery.Name,
+			Digest:        &common.Digest{Summary: digest.Summary, Reports: reports},
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error rendering digest template for %s: %v", to, err))
+			continue
+		}
+
+		err = e.Service.SendEmail(email.Message{
+			To:          []string{to},
+			Cc:          delivery.Cc,
+			Bcc:         delivery.Bcc,
+			Subject:     email.DigestSubjectName,
+			Body:        body,
+			Inline:      inline,
+			Attachments: attachments,
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error sending digest to %s: %v", to, err))
+			continue
+		}
+		log.Printf("Digest email sent successfully, insights from %d files have been sent to %s", len(reports), to)
+	}
+	return errors.Join(errs...)
+}
+
+// inlineCharts returns the inline attachments for the charts of the given sections, and their cid: URLs by section.
+// A non-empty prefix is added to the content IDs and file names, to keep the charts of several files apart.
+func inlineCharts(charts []chart.Chart, sections []common.Section, prefix string) ([]email.Attachment, map[common.Section]template.URL) {
+	var inline []email.Attachment
+	urls := make(map[common.Section]template.URL)
+	for _, c := range charts {
+		if !common.IncludesSection(sections, c.Section) {
+			continue
+		}
+		attachment := email.Attachment{
+			Filename:    c.Filename(),
+			ContentType: c.ContentType(),
+			ContentID:   c.ContentID(),
+			Data:        c.Data,
+		}
+		url := c.CID()
+		if prefix != "" {
+			attachment.Filename = prefix + "-" + attachment.Filename
+			attachment.ContentID = prefix + "." + attachment.ContentID
+			url = template.URL("cid:" + attachment.ContentID)
+		}
+		inline = append(inline, attachment)
+		urls[c.Section] = url
+	}
+	return inline, urls
+}
+
+// digestAnchor returns the HTML id of a property's report in a digest, made unique by its position.
+func digestAnchor(name string, index int) string {
+	slug := strings.Trim(nonAnchorChars.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	return fmt.Sprintf("property-%d-%s", index+1, slug)
+}
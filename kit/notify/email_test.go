@@ -0,0 +1,94 @@
+package notify
+
+import (
+	"data-insights/kit/common"
+	"data-insights/kit/email"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// fakeEmailService records the recipients of the messages it sends and fails for the addresses in fail.
+type fakeEmailService struct {
+	fail map[string]bool
+	sent []string
+}
+
+func (s *fakeEmailService) SendEmail(message email.Message) error {
+	s.sent = append(s.sent, message.To...)
+	if s.fail[message.To[0]] {
+		return errors.New("mailbox unavailable")
+	}
+	return nil
+}
+
+type fakeRenderer struct{}
+
+func (fakeRenderer) Render(emailData common.EmailData) (string, error) {
+	return "<p>Hello " + emailData.RecipientName + "</p>", nil
+}
+
+func testRecipients() email.RecipientsConfig {
+	return email.RecipientsConfig{Recipients: []email.Recipient{
+		{Name: "Alice", Email: "alice@example.com"},
+		{Name: "Bob", Email: "bob@example.com"},
+		{Name: "Carol", Email: "carol@example.com"},
+	}}
+}
+
+func TestEmailNotifierTriesEveryRecipient(t *testing.T) {
+	service := &fakeEmailService{fail: map[string]bool{"alice@example.com": true}}
+	notifier := NewEmailNotifier(service, fakeRenderer{}, testRecipients())
+
+	err := notifier.Notify(testNotification())
+	var recipientsErr *RecipientsError
+	if !errors.As(err, &recipientsErr) {
+		t.Fatalf("got error %v, want a RecipientsError", err)
+	}
+	if want := []string{"alice@example.com", "bob@example.com", "carol@example.com"}; !reflect.DeepEqual(service.sent, want) {
+		t.Errorf("sent to %v, want %v", service.sent, want)
+	}
+	if want := []string{"bob@example.com", "carol@example.com"}; !reflect.DeepEqual(recipientsErr.Delivered, want) {
+		t.Errorf("got delivered %v, want %v", recipientsErr.Delivered, want)
+	}
+}
+
+func TestEmailNotifierSkipsDeliveredRecipients(t *testing.T) {
+	service := &fakeEmailService{}
+	notifier := NewEmailNotifier(service, fakeRenderer{}, testRecipients())
+	notification := testNotification()
+	notification.Delivered = []string{"bob@example.com", "carol@example.com"}
+
+	if err := notifier.Notify(notification); err != nil {
+		t.Fatalf("failed to notify: %v", err)
+	}
+	if want := []string{"alice@example.com"}; !reflect.DeepEqual(service.sent, want) {
+		t.Errorf("sent to %v, want %v", service.sent, want)
+	}
+}
+
+func TestEmailNotifierDigestTriesEveryRecipient(t *testing.T) {
+	service := &fakeEmailService{fail: map[string]bool{"alice@example.com": true}}
+	notifier := NewEmailNotifier(service, fakeRenderer{}, testRecipients())
+
+	if err := notifier.NotifyDigest(Digest{Notifications: []Notification{testNotification()}}); err == nil {
+		t.Fatal("got no error for a failed recipient")
+	}
+	if len(service.sent) != 3 {
+		t.Errorf("sent to %v, want every recipient", service.sent)
+	}
+}
+
+func TestDispatchReportsDeliveredRecipients(t *testing.T) {
+	service := &fakeEmailService{fail: map[string]bool{"carol@example.com": true}}
+	targets := []Target{{Channel: Channel{Type: ChannelEmail}, Notifier: NewEmailNotifier(service, fakeRenderer{}, testRecipients())}}
+
+	err := Dispatch(targets, testNotification())
+	var recipientsErr *RecipientsError
+	if !errors.As(err, &recipientsErr) {
+		t.Fatalf("got error %v, want a RecipientsError", err)
+	}
+	if want := []string{"alice@example.com", "bob@example.com"}; !reflect.DeepEqual(recipientsErr.Delivered, want) {
+		t.Errorf("got delivered %v, want %v", recipientsErr.Delivered, want)
+	}
+}
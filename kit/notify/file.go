@@ -0,0 +1,52 @@
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FileNotifier writes the payload a webhook channel would post to disk instead of posting it, for dry runs.
+type FileNotifier struct {
+	Dir     string
+	Channel ChannelType
+
+	mu    sync.Mutex
+	count int
+}
+
+func NewFileNotifier(dir string, channel ChannelType) *FileNotifier {
+	return &FileNotifier{Dir: dir, Channel: channel}
+}
+
+// Notify writes the channel's payload for the notification as indented JSON, named after the time, a sequence
+// number and the channel.
+func (f *FileNotifier) Notify(notification Notification) error {
+	payload, err := Payload(f.Channel, notification)
+	if err != nil {
+		return err
+	}
+	content, err := json.MarshalIndent(payload, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %v", err)
+	}
+	if err := os.MkdirAll(f.Dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create dry run directory: %v", err)
+	}
+
+	f.mu.Lock()
+	f.count++
+	count := f.count
+	f.mu.Unlock()
+
+	path := filepath.Join(f.Dir, fmt.Sprintf("%s-%03d-%s.json", time.Now().Format("20060102-150405"), count, f.Channel))
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		return fmt.Errorf("failed to write payload: %v", err)
+	}
+	log.Printf("Dry run: %s payload written to %s", f.Channel, path)
+	return nil
+}
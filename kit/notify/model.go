@@ -0,0 +1,108 @@
+package notify
+
+import (
+	"data-insights/kit/chart"
+	"data-insights/kit/common"
+	"data-insights/kit/email"
+	"encoding/json"
+)
+
+// Notification is a processed report, ready to be delivered to a channel.
+type Notification struct {
+	SourceFile  string
+	Insights    common.UserMetricsWithInsights
+	ToolCalls   []common.ToolCallLog
+	Sections    []common.Section // sections to deliver, all when empty
+	Charts      []chart.Chart
+	Attachments []email.Attachment     // attached to emails only
+	Property    common.PropertyMetrics // the file's overall metrics, compared across files in digests
+	Delivered   []string               // email recipients that received the file in an earlier, failed delivery
+}
+
+// Digest is the notifications of several data files, delivered together with a cross-property summary.
+type Digest struct {
+	Summary       string
+	Notifications []Notification
+}
+
+type Channel struct {
+	Type     ChannelType      `json:"type"`
+	URL      string           `json:"url,omitempty"`
+	Files    []string         `json:"files,omitempty"`
+	Sections []common.Section `json:"sections,omitempty"`
+}
+
+type ChannelsConfig struct {
+	Channels []Channel `json:"channels"`
+}
+
+// Target pairs a configured channel with the notifier that delivers to it.
+type Target struct {
+	Channel  Channel
+	Notifier Notifier
+}
+
+type SlackMessage struct {
+	Text   string       `json:"text"` // fallback for notifications
+	Blocks []SlackBlock `json:"blocks"`
+}
+
+type SlackBlock struct {
+	Type     string      `json:"type"`
+	Text     *SlackText  `json:"text,omitempty"`
+	Fields   []SlackText `json:"fields,omitempty"`
+	Elements []SlackText `json:"elements,omitempty"`
+}
+
+type SlackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type TeamsMessage struct {
+	Type        string            `json:"type"`
+	Attachments []TeamsAttachment `json:"attachments"`
+}
+
+type TeamsAttachment struct {
+	ContentType string       `json:"contentType"`
+	Content     AdaptiveCard `json:"content"`
+}
+
+type AdaptiveCard struct {
+	Schema  string             `json:"$schema"`
+	Type    string             `json:"type"`
+	Version string             `json:"version"`
+	Body    []AdaptiveElement  `json:"body"`
+	MSTeams *AdaptiveCardTeams `json:"msteams,omitempty"`
+}
+
+type AdaptiveCardTeams struct {
+	Width string `json:"width"`
+}
+
+type AdaptiveElement struct {
+	Type      string         `json:"type"`
+	Text      string         `json:"text,omitempty"`
+	Size      string         `json:"size,omitempty"`
+	Weight    string         `json:"weight,omitempty"`
+	IsSubtle  bool           `json:"isSubtle,omitempty"`
+	Wrap      bool           `json:"wrap,omitempty"`
+	Separator bool           `json:"separator,omitempty"`
+	Spacing   string         `json:"spacing,omitempty"`
+	Facts     []AdaptiveFact `json:"facts,omitempty"`
+}
+
+type AdaptiveFact struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+}
+
+// WebhookPayload is the JSON body posted to generic webhooks. Report only holds the delivered sections, keyed by
+// section name.
+type WebhookPayload struct {
+	Title      string                             `json:"title"`
+	SourceFile string                             `json:"source_file"`
+	Report     map[common.Section]json.RawMessage `json:"report"`
+	ToolCalls  []common.ToolCallLog               `json:"tool_calls,omitempty"`
+}
@@ -0,0 +1,114 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+)
+
+type Notifier interface {
+	Notify(notification Notification) error
+}
+
+// DigestNotifier is implemented by notifiers that can deliver several data files in one message.
+type DigestNotifier interface {
+	NotifyDigest(digest Digest) error
+}
+
+// Dispatch delivers the notification to every target whose channel receives the data file, with the channel's
+// sections. A failing channel does not stop the others; their errors are returned together.
+func Dispatch(targets []Target, notification Notification) error {
+	var errs []error
+	for _, target := range targets {
+		if !target.Channel.receives(notification.SourceFile) {
+			continue
+		}
+		channelNotification := notification
+		channelNotification.Sections = target.Channel.Sections
+		if err := target.Notifier.Notify(channelNotification); err != nil {
+			errs = append(errs, fmt.Errorf("%s channel: %w", target.Channel.Type, err))
+			continue
+		}
+		log.Printf("Insights from the file %s have been delivered to the %s channel", notification.SourceFile, target.Channel.Type)
+	}
+	return errors.Join(errs...)
+}
+
+// DispatchDigest delivers the digest to every target, with the files its channel receives and the channel's
+// sections. Channels that cannot deliver digests receive one notification per file instead. A failing channel
+// does not stop the others; their errors are returned together.
+func DispatchDigest(targets []Target, digest Digest) error {
+	var errs []error
+	for _, target := range targets {
+		channelDigest := Digest{Summary: digest.Summary}
+		for _, notification := range digest.Notifications {
+			if !target.Channel.receives(notification.SourceFile) {
+				continue
+			}
+			notification.Sections = target.Channel.Sections
+			channelDigest.Notifications = append(channelDigest.Notifications, notification)
+		}
+		if len(channelDigest.Notifications) == 0 {
+			continue
+		}
+
+		if digestNotifier, ok := target.Notifier.(DigestNotifier); ok {
+			if err := digestNotifier.NotifyDigest(channelDigest); err != nil {
+				errs = append(errs, fmt.Errorf("%s channel: %w", target.Channel.Type, err))
+				continue
+			}
+			log.Printf("Digest of %d files has been delivered to the %s channel", len(channelDigest.Notifications), target.Channel.Type)
+			continue
+		}
+		for _, notification := range channelDigest.Notifications {
+			if err := target.Notifier.Notify(notification); err != nil {
+				errs = append(errs, fmt.Errorf("%s channel: %w", target.Channel.Type, err))
+				continue
+			}
+			log.Printf("Insights from the file %s have been delivered to the %s channel", notification.SourceFile, target.Channel.Type)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Payload returns the JSON payload that the webhook channel posts for the notification.
+func Payload(channel ChannelType, notification Notification) (interface{}, error) {
+	switch channel {
+	case ChannelSlack:
+		return NewSlackMessage(notification), nil
+	case ChannelTeams:
+		return NewTeamsMessage(notification), nil
+	case ChannelWebhook:
+		return NewWebhookPayload(notification)
+	default:
+		return nil, fmt.Errorf("the %s channel has no JSON payload", channel)
+	}
+}
+
+// postJSON posts the payload as JSON to url. It returns an error if the request fails or the endpoint responds
+// with a status other than 2xx.
+func postJSON(httpClient *http.Client, url string, payload interface{}) error {
+	requestBody, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %v", err)
+	}
+
+	if httpClient == nil {
+		httpClient = &http.Client{}
+	}
+	resp, err := httpClient.Post(url, "application/json", bytes.NewBuffer(requestBody))
+	if err != nil {
+		return fmt.Errorf("failed to post payload: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("webhook responded with status %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package notify
+
+import (
+	"data-insights/kit/common"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// webhookServer is a webhook endpoint that records the bodies posted to it and responds with status.
+type webhookServer struct {
+	*httptest.Server
+	mu     sync.Mutex
+	bodies [][]byte
+}
+
+func newWebhookServer(t *testing.T, status int) *webhookServer {
+	t.Helper()
+	server := &webhookServer{}
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			http.Error(w, "expected a JSON POST", http.StatusBadRequest)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		server.mu.Lock()
+		server.bodies = append(server.bodies, body)
+		server.mu.Unlock()
+		if status >= http.StatusMultipleChoices {
+			http.Error(w, "rejected by the test server", status)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// decode unmarshals the only body posted to the server into v.
+func (s *webhookServer) decode(t *testing.T, v interface{}) {
+	t.Helper()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.bodies) != 1 {
+		t.Fatalf("got %d requests, want 1", len(s.bodies))
+	}
+	if err := json.Unmarshal(s.bodies[0], v); err != nil {
+		t.Fatalf("failed to decode payload: %v", err)
+	}
+}
+
+func (s *webhookServer) requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.bodies)
+}
+
+func testNotification() Notification {
+	return Notification{
+		SourceFile: "data/shop-august.json",
+		Insights: common.UserMetricsWithInsights{
+			ExecutiveSummary: common.ExecutiveSummary{
+				Summary: "Engagement grew <fast> & steadily",
+				Recommendations: []common.Recommendation{
+					{Rank: 1, Action: "Improve the mobile checkout", ExpectedImpact: "Lower bounce rate", Confidence: "high"},
+				},
+			},
+			OverallMetrics: common.OverallMetricsWithInsight{
+				OverallEngagementRate: "61.00%",
+				BounceRate:            "39.00%",
+				AIInsight:             "Engagement is healthy",
+			},
+			BounceRatesByDevices: common.AggregatedMetricsWithInsight{
+				AIInsight:         "Mobile bounces more",
+				AggregatedMetrics: []common.AggregatedMetric{{Name: "mobile", BounceRate: "48.00%"}, {Name: "desktop", BounceRate: "30.00%"}},
+			},
+		},
+		ToolCalls: []common.ToolCallLog{{Name: "get_metrics", Arguments: "{}", Result: "ok"}},
+	}
+}
+
+func TestNotifiersReportErrorStatuses(t *testing.T) {
+	notifiers := map[ChannelType]func(url string) Notifier{
+		ChannelSlack:   func(url string) Notifier { return NewSlackNotifier(url, nil) },
+		ChannelTeams:   func(url string) Notifier { return NewTeamsNotifier(url, nil) },
+		ChannelWebhook: func(url string) Notifier { return NewWebhookNotifier(url, nil) },
+	}
+	for channel, newNotifier := range notifiers {
+		for _, status := range []int{http.StatusOK, http.StatusNoContent, http.StatusMultipleChoices, http.StatusNotFound, http.StatusInternalServerError} {
+			server := newWebhookServer(t, status)
+			err := newNotifier(server.URL).Notify(testNotification())
+			if status < http.StatusMultipleChoices {
+				if err != nil {
+					t.Errorf("%s channel: got error %v for status %d", channel, err, status)
+				}
+				continue
+			}
+			if err == nil || !strings.Contains(err.Error(), "status") || !strings.Contains(err.Error(), "rejected by the test server") {
+				t.Errorf("%s channel: got error %v for status %d, want the status and the response body", channel, err, status)
+			}
+		}
+	}
+}
+
+func TestDispatchReachesEveryTarget(t *testing.T) {
+	slack := newWebhookServer(t, http.StatusOK)
+	teams := newWebhookServer(t, http.StatusOK)
+	webhook := newWebhookServer(t, http.StatusOK)
+	failing := newWebhookServer(t, http.StatusInternalServerError)
+	otherFiles := newWebhookServer(t, http.StatusOK)
+
+	targets := []Target{
+		{Channel: Channel{Type: ChannelWebhook, URL: failing.URL}, Notifier: NewWebhookNotifier(failing.URL, nil)},
+		{Channel: Channel{Type: ChannelSlack, URL: slack.URL}, Notifier: NewSlackNotifier(slack.URL, nil)},
+		{Channel: Channel{Type: ChannelTeams, URL: teams.URL}, Notifier: NewTeamsNotifier(teams.URL, nil)},
+		{Channel: Channel{Type: ChannelWebhook, URL: webhook.URL, Sections: []common.Section{common.OVERALLSECTION}}, Notifier: NewWebhookNotifier(webhook.URL, nil)},
+		{Channel: Channel{Type: ChannelSlack, URL: otherFiles.URL, Files: []string{"blog-*"}}, Notifier: NewSlackNotifier(otherFiles.URL, nil)},
+	}
+	err := Dispatch(targets, testNotification())
+	if err == nil || !strings.Contains(err.Error(), "webhook channel") {
+		t.Errorf("got error %v, want the failing webhook channel's error", err)
+	}
+
+	for name, server := range map[string]*webhookServer{"failing": failing, "slack": slack, "teams": teams, "webhook": webhook} {
+		if got := server.requests(); got != 1 {
+			t.Errorf("%s target got %d requests, want 1", name, got)
+		}
+	}
+	if got := otherFiles.requests(); got != 0 {
+		t.Errorf("a target for other files got %d requests, want 0", got)
+	}
+
+	var payload WebhookPayload
+	webhook.decode(t, &payload)
+	if _, ok := payload.Report[common.OVERALLSECTION]; !ok || len(payload.Report) != 1 {
+		t.Errorf("got report sections %v, want only the channel's section", payload.Report)
+	}
+}
+
+func TestDispatchDigestReachesEveryTarget(t *testing.T) {
+	slack := newWebhookServer(t, http.StatusOK)
+	webhook := newWebhookServer(t, http.StatusOK)
+	targets := []Target{
+		{Channel: Channel{Type: ChannelSlack, URL: slack.URL}, Notifier: NewSlackNotifier(slack.URL, nil)},
+		{Channel: Channel{Type: ChannelWebhook, URL: webhook.URL, Files: []string{"shop-*"}}, Notifier: NewWebhookNotifier(webhook.URL, nil)},
+	}
+	blog := testNotification()
+	blog.SourceFile = "data/blog-august.json"
+	digest := Digest{Summary: "Two properties", Notifications: []Notification{testNotification(), blog}}
+
+	if err := DispatchDigest(targets, digest); err != nil {
+		t.Fatalf("failed to dispatch digest: %v", err)
+	}
+	// Webhook channels cannot deliver digests, so they receive one notification per file they select
+	if got := slack.requests(); got != 2 {
+		t.Errorf("slack target got %d requests, want 2", got)
+	}
+	if got := webhook.requests(); got != 1 {
+		t.Errorf("webhook target got %d requests, want 1", got)
+	}
+}
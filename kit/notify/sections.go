@@ -0,0 +1,24 @@
+package notify
+
+import (
+	"data-insights/kit/common"
+	"data-insights/kit/report"
+)
+
+// metricSections returns the metrics sections of the report that the notification includes, in report order.
+func (n Notification) metricSections() []report.Section {
+	return report.MetricSections(n.Insights, n.Sections)
+}
+
+// summary returns the executive summary, or nil if the notification does not include it or it is empty.
+func (n Notification) summary() *common.ExecutiveSummary {
+	summary := n.Insights.ExecutiveSummary
+	if !n.includes(common.SUMMARYSECTION) || (summary.Summary == "" && len(summary.Recommendations) == 0) {
+		return nil
+	}
+	return &summary
+}
+
+func (n Notification) includes(section common.Section) bool {
+	return common.IncludesSection(n.Sections, section)
+}
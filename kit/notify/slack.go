@@ -0,0 +1,92 @@
+package notify
+
+import (
+	"data-insights/kit/report"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+type SlackNotifier struct {
+	WebhookURL string
+	HttpClient *http.Client
+}
+
+func NewSlackNotifier(webhookURL string, httpClient *http.Client) *SlackNotifier {
+	return &SlackNotifier{WebhookURL: webhookURL, HttpClient: httpClient}
+}
+
+// Notify posts the report to the Slack incoming webhook as a Block Kit message.
+func (s *SlackNotifier) Notify(notification Notification) error {
+	return postJSON(s.HttpClient, s.WebhookURL, NewSlackMessage(notification))
+}
+
+// NewSlackMessage renders the notification as Block Kit blocks: a header, the executive summary and its
+// recommendations, then one section per metrics section with its insight and values as fields.
+// Texts are escaped and truncated to Slack's limits.
+func NewSlackMessage(notification Notification) SlackMessage {
+	source := filepath.Base(notification.SourceFile)
+	blocks := []SlackBlock{
+		{Type: "header", Text: &SlackText{Type: "plain_text", Text: ReportTitle}},
+		{Type: "context", Elements: []SlackText{slackMarkdown("Insights from `" + source + "`")}},
+	}
+
+	if summary := notification.summary(); summary != nil {
+		blocks = append(blocks, slackSection("*Executive Summary*\n"+slackEscape(summary.Summary)))
+		if len(summary.Recommendations) > 0 {
+			lines := make([]string, 0, len(summary.Recommendations))
+			for _, recommendation := range summary.Recommendations {
+				lines = append(lines, slackEscape(report.FormatRecommendation(recommendation)))
+			}
+			blocks = append(blocks, slackSection("*Recommendations*\n"+strings.Join(lines, "\n")))
+		}
+	}
+
+	for _, section := range notification.metricSections() {
+		block := slackSection("*" + section.Title + "*\n" + slackEscape(section.Insight))
+		for i, f := range section.Rows {
+			if i == SlackMaxFields {
+				break
+			}
+			block.Fields = append(block.Fields, slackField("*"+slackEscape(f.Name)+"*\n"+slackEscape(f.Value)))
+		}
+		blocks = append(blocks, SlackBlock{Type: "divider"}, block)
+	}
+
+	if len(blocks) > SlackMaxBlocks {
+		blocks = blocks[:SlackMaxBlocks]
+	}
+	return SlackMessage{
+		Text:   fmt.Sprintf("%s: insights from %s", ReportTitle, source),
+		Blocks: blocks,
+	}
+}
+
+func slackSection(text string) SlackBlock {
+	text = truncate(text, SlackMaxTextLength)
+	return SlackBlock{Type: "section", Text: &SlackText{Type: "mrkdwn", Text: text}}
+}
+
+func slackMarkdown(text string) SlackText {
+	return SlackText{Type: "mrkdwn", Text: truncate(text, SlackMaxTextLength)}
+}
+
+// slackField returns a section field, whose text Slack limits to fewer characters than other texts.
+func slackField(text string) SlackText {
+	return SlackText{Type: "mrkdwn", Text: truncate(text, SlackMaxFieldLength)}
+}
+
+// slackEscape escapes the characters that Slack treats as control sequences in mrkdwn.
+func slackEscape(text string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+}
+
+// truncate shortens text to at most limit characters, ending it with an ellipsis when it is cut.
+func truncate(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return string(runes[:limit-1]) + "…"
+}
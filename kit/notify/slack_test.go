@@ -0,0 +1,71 @@
+package notify
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestSlackNotifierPostsBlockKit(t *testing.T) {
+	server := newWebhookServer(t, http.StatusOK)
+	if err := NewSlackNotifier(server.URL, nil).Notify(testNotification()); err != nil {
+		t.Fatalf("failed to notify: %v", err)
+	}
+
+	var message SlackMessage
+	server.decode(t, &message)
+	if !strings.Contains(message.Text, "shop-august.json") {
+		t.Errorf("got fallback text %q, want the source file", message.Text)
+	}
+	if len(message.Blocks) == 0 || message.Blocks[0].Type != "header" || message.Blocks[0].Text.Text != ReportTitle {
+		t.Fatalf("got blocks %+v, want a header first", message.Blocks)
+	}
+
+	var texts []string
+	for _, block := range message.Blocks {
+		if block.Type == "section" {
+			texts = append(texts, block.Text.Text)
+		}
+	}
+	all := strings.Join(texts, "\n")
+	for _, want := range []string{
+		"*Executive Summary*\nEngagement grew &lt;fast&gt; &amp; steadily",
+		"*Recommendations*\n",
+		"*Overall Metrics*\nEngagement is healthy",
+		"*Bounce Rates by Devices*\nMobile bounces more",
+	} {
+		if !strings.Contains(all, want) {
+			t.Errorf("section texts do not contain %q:\n%s", want, all)
+		}
+	}
+
+	var fields []SlackText
+	for _, block := range message.Blocks {
+		if block.Text != nil && strings.HasPrefix(block.Text.Text, "*Bounce Rates by Devices*") {
+			fields = block.Fields
+		}
+	}
+	if len(fields) != 2 || fields[0].Text != "*mobile*\n48.00%" {
+		t.Errorf("got fields %+v, want one per device", fields)
+	}
+}
+
+func TestNewSlackMessageStaysWithinLimits(t *testing.T) {
+	notification := testNotification()
+	notification.Insights.OverallMetrics.AIInsight = strings.Repeat("a", 2*SlackMaxTextLength)
+	notification.Insights.BounceRatesByDevices.AggregatedMetrics[0].Name = strings.Repeat("b", SlackMaxTextLength)
+
+	for _, block := range NewSlackMessage(notification).Blocks {
+		if block.Text != nil && len([]rune(block.Text.Text)) > SlackMaxTextLength {
+			t.Errorf("got a %d character text, want at most %d", len([]rune(block.Text.Text)), SlackMaxTextLength)
+		}
+		if len(block.Fields) > SlackMaxFields {
+			t.Errorf("got %d fields, want at most %d", len(block.Fields), SlackMaxFields)
+		}
+		for _, field := range block.Fields {
+			if len([]rune(field.Text)) > SlackMaxFieldLength {
+				t.Errorf("got a %d character field, want at most %d", len([]rune(field.Text)), SlackMaxFieldLength)
+			}
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package notify
+
+import (
+	"data-insights/kit/report"
+	"net/http"
+	"path/filepath"
+)
+
+type TeamsNotifier struct {
+	WebhookURL string
+	HttpClient *http.Client
+}
+
+func NewTeamsNotifier(webhookURL string, httpClient *http.Client) *TeamsNotifier {
+	return &TeamsNotifier{WebhookURL: webhookURL, HttpClient: httpClient}
+}
+
+// Notify posts the report to the Microsoft Teams incoming webhook as an Adaptive Card.
+func (t *TeamsNotifier) Notify(notification Notification) error {
+	return postJSON(t.HttpClient, t.WebhookURL, NewTeamsMessage(notification))
+}
+
+// NewTeamsMessage renders the notification as an Adaptive Card: a title, the executive summary and its
+// recommendations, then one block per metrics section with its insight and values as a fact set.
+func NewTeamsMessage(notification Notification) TeamsMessage {
+	body := []AdaptiveElement{
+		{Type: "TextBlock", Text: ReportTitle, Size: "Large", Weight: "Bolder", Wrap: true},
+		{Type: "TextBlock", Text: "Insights from " + filepath.Base(notification.SourceFile), IsSubtle: true, Spacing: "None", Wrap: true},
+	}
+
+	if summary := notification.summary(); summary != nil {
+		body = append(body,
+			adaptiveHeading("Executive Summary"),
+			AdaptiveElement{Type: "TextBlock", Text: summary.Summary, Wrap: true},
+		)
+		for _, recommendation := range summary.Recommendations {
+			body = append(body, AdaptiveElement{Type: "TextBlock", Text: report.FormatRecommendation(recommendation), Wrap: true})
+		}
+	}
+
+	for _, section := range notification.metricSections() {
+		facts := make([]AdaptiveFact, 0, len(section.Rows))
+		for _, f := range section.Rows {
+			facts = append(facts, AdaptiveFact{Title: f.Name, Value: f.Value})
+		}
+		body = append(body,
+			adaptiveHeading(section.Title),
+			AdaptiveElement{Type: "TextBlock", Text: section.Insight, Wrap: true},
+			AdaptiveElement{Type: "FactSet", Facts: facts},
+		)
+	}
+
+	return TeamsMessage{
+		Type: "message",
+		Attachments: []TeamsAttachment{{
+			ContentType: AdaptiveCardContentType,
+			Content: AdaptiveCard{
+				Schema:  AdaptiveCardSchema,
+				Type:    "AdaptiveCard",
+				Version: AdaptiveCardVersion,
+				Body:    body,
+				MSTeams: &AdaptiveCardTeams{Width: "Full"},
+			},
+		}},
+	}
+}
+
+func adaptiveHeading(text string) AdaptiveElement {
+	return AdaptiveElement{Type: "TextBlock", Text: text, Size: "Medium", Weight: "Bolder", Separator: true, Wrap: true}
+}
@@ -0,0 +1,51 @@
+package notify
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestTeamsNotifierPostsAdaptiveCard(t *testing.T) {
+	server := newWebhookServer(t, http.StatusOK)
+	if err := NewTeamsNotifier(server.URL, nil).Notify(testNotification()); err != nil {
+		t.Fatalf("failed to notify: %v", err)
+	}
+
+	var message TeamsMessage
+	server.decode(t, &message)
+	if message.Type != "message" || len(message.Attachments) != 1 {
+		t.Fatalf("got message %+v, want one attachment", message)
+	}
+	attachment := message.Attachments[0]
+	if attachment.ContentType != AdaptiveCardContentType {
+		t.Errorf("got content type %q, want %q", attachment.ContentType, AdaptiveCardContentType)
+	}
+	card := attachment.Content
+	if card.Type != "AdaptiveCard" || card.Version != AdaptiveCardVersion || card.Schema != AdaptiveCardSchema {
+		t.Errorf("got card %s %s %s, want an Adaptive Card %s", card.Type, card.Version, card.Schema, AdaptiveCardVersion)
+	}
+
+	texts := map[string]bool{}
+	var factSets [][]AdaptiveFact
+	for _, element := range card.Body {
+		switch element.Type {
+		case "TextBlock":
+			texts[element.Text] = true
+		case "FactSet":
+			factSets = append(factSets, element.Facts)
+		}
+	}
+	for _, want := range []string{ReportTitle, "Insights from shop-august.json", "Executive Summary", "Engagement grew <fast> & steadily", "Overall Metrics", "Bounce Rates by Devices", "Mobile bounces more"} {
+		if !texts[want] {
+			t.Errorf("the card has no text block %q", want)
+		}
+	}
+
+	// All metrics sections are included, one fact set each
+	if len(factSets) != 7 {
+		t.Fatalf("got %d fact sets, want 7", len(factSets))
+	}
+	if facts := factSets[0]; facts[0] != (AdaptiveFact{Title: "Overall Engagement Rate", Value: "61.00%"}) {
+		t.Errorf("got overall facts %+v", facts)
+	}
+}
@@ -0,0 +1,51 @@
+package notify
+
+import (
+	"data-insights/kit/common"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+type WebhookNotifier struct {
+	URL        string
+	HttpClient *http.Client
+}
+
+func NewWebhookNotifier(url string, httpClient *http.Client) *WebhookNotifier {
+	return &WebhookNotifier{URL: url, HttpClient: httpClient}
+}
+
+// Notify posts the report to the webhook as a WebhookPayload.
+func (w *WebhookNotifier) Notify(notification Notification) error {
+	payload, err := NewWebhookPayload(notification)
+	if err != nil {
+		return err
+	}
+	return postJSON(w.HttpClient, w.URL, payload)
+}
+
+// NewWebhookPayload returns the payload for the notification. The report keeps the JSON shape of
+// UserMetricsWithInsights, restricted to the notification's sections.
+func NewWebhookPayload(notification Notification) (WebhookPayload, error) {
+	content, err := json.Marshal(notification.Insights)
+	if err != nil {
+		return WebhookPayload{}, fmt.Errorf("failed to marshal insights: %v", err)
+	}
+	var report map[common.Section]json.RawMessage
+	if err := json.Unmarshal(content, &report); err != nil {
+		return WebhookPayload{}, fmt.Errorf("failed to unmarshal insights: %v", err)
+	}
+	for section := range report {
+		if !notification.includes(section) {
+			delete(report, section)
+		}
+	}
+
+	return WebhookPayload{
+		Title:      ReportTitle,
+		SourceFile: notification.SourceFile,
+		Report:     report,
+		ToolCalls:  notification.ToolCalls,
+	}, nil
+}
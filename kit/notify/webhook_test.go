@@ -0,0 +1,44 @@
+package notify
+
+import (
+	"data-insights/kit/common"
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestWebhookNotifierPostsReport(t *testing.T) {
+	server := newWebhookServer(t, http.StatusOK)
+	notification := testNotification()
+	notification.Sections = []common.Section{common.SUMMARYSECTION, common.BOUNCERATESSECTION}
+	if err := NewWebhookNotifier(server.URL, nil).Notify(notification); err != nil {
+		t.Fatalf("failed to notify: %v", err)
+	}
+
+	var payload WebhookPayload
+	server.decode(t, &payload)
+	if payload.Title != ReportTitle || payload.SourceFile != notification.SourceFile {
+		t.Errorf("got title %q and source file %q", payload.Title, payload.SourceFile)
+	}
+	if len(payload.ToolCalls) != 1 || payload.ToolCalls[0].Name != "get_metrics" {
+		t.Errorf("got tool calls %+v", payload.ToolCalls)
+	}
+	if len(payload.Report) != 2 {
+		t.Fatalf("got report sections %v, want only the selected ones", payload.Report)
+	}
+
+	var summary common.ExecutiveSummary
+	if err := json.Unmarshal(payload.Report[common.SUMMARYSECTION], &summary); err != nil {
+		t.Fatalf("failed to decode the summary: %v", err)
+	}
+	if summary.Summary != notification.Insights.ExecutiveSummary.Summary || len(summary.Recommendations) != 1 {
+		t.Errorf("got summary %+v", summary)
+	}
+	var bounceRates common.AggregatedMetricsWithInsight
+	if err := json.Unmarshal(payload.Report[common.BOUNCERATESSECTION], &bounceRates); err != nil {
+		t.Fatalf("failed to decode the bounce rates: %v", err)
+	}
+	if bounceRates.AIInsight != "Mobile bounces more" || len(bounceRates.AggregatedMetrics) != 2 {
+		t.Errorf("got bounce rates %+v", bounceRates)
+	}
+}
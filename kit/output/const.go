@@ -0,0 +1,22 @@
+package output
+
+type Format string
+
+const (
+	FormatHTML     Format = "html"
+	FormatMarkdown Format = "markdown"
+	FormatJSON     Format = "json"
+)
+
+// DefaultPathTemplate writes every report to the output directory, named after the stored report, whose ID is
+// unique.
+const DefaultPathTemplate = "output/{{.ID}}.{{.Ext}}"
+
+// ChartsDirSuffix names the directory next to a Markdown report that holds its chart images.
+const ChartsDirSuffix = "_charts"
+
+var extensions = map[Format]string{
+	FormatHTML:     "html",
+	FormatMarkdown: "md",
+	FormatJSON:     "json",
+}
@@ -0,0 +1,90 @@
+package output
+
+import (
+	"data-insights/kit/common"
+	"data-insights/kit/report"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// RenderMarkdown renders the report as GitHub-flavoured Markdown with the same content as the email template.
+// Charts are linked from chartLinks, relative to the Markdown file, for the sections that have one.
+func RenderMarkdown(r report.Report, chartLinks map[common.Section]string) string {
+	var b strings.Builder
+	b.WriteString("# Website Insights Report\n\n")
+	fmt.Fprintf(&b, "_Insights from `%s`, generated on %s._\n\n", filepath.Base(r.SourceFile), r.CreatedAt.Format("2006-01-02 15:04 MST"))
+
+	summary := r.Insights.ExecutiveSummary
+	if summary.Summary != "" || len(summary.Recommendations) > 0 {
+		b.WriteString("## Executive Summary\n\n")
+		writeParagraph(&b, summary.Summary)
+		if len(summary.Recommendations) > 0 {
+			b.WriteString("### Recommendations\n\n")
+			rows := make([][]string, 0, len(summary.Recommendations))
+			for _, recommendation := range summary.Recommendations {
+				rows = append(rows, []string{
+					strconv.Itoa(recommendation.Rank),
+					recommendation.Action,
+					recommendation.ExpectedImpact,
+					recommendation.Confidence,
+					report.FormatEvidence(recommendation.Evidence),
+				})
+			}
+			writeTable(&b, []string{"#", "Recommendation", "Expected Impact", "Confidence", "Evidence"}, rows)
+		}
+	}
+
+	for _, section := range report.MetricSections(r.Insights, nil) {
+		fmt.Fprintf(&b, "## %s\n\n", section.Title)
+		writeParagraph(&b, section.Insight)
+		if link, ok := chartLinks[section.Section]; ok {
+			fmt.Fprintf(&b, "![%s chart](%s)\n\n", section.Title, link)
+		}
+		rows := make([][]string, 0, len(section.Rows))
+		for _, row := range section.Rows {
+			rows = append(rows, []string{row.Name, row.Value})
+		}
+		writeTable(&b, []string{section.NameHeader, section.ValueHeader}, rows)
+	}
+
+	if len(r.ToolCalls) > 0 {
+		b.WriteString("## Appendix: Data Queries\n\n")
+		b.WriteString("The following queries were run against the raw data while the insights were written:\n\n")
+		rows := make([][]string, 0, len(r.ToolCalls))
+		for _, call := range r.ToolCalls {
+			rows = append(rows, []string{call.Name, "`" + call.Arguments + "`"})
+		}
+		writeTable(&b, []string{"Query", "Arguments"}, rows)
+	}
+	return b.String()
+}
+
+func writeParagraph(b *strings.Builder, text string) {
+	if text = strings.TrimSpace(text); text != "" {
+		b.WriteString(text + "\n\n")
+	}
+}
+
+func writeTable(b *strings.Builder, headers []string, rows [][]string) {
+	writeRow(b, headers)
+	separators := make([]string, len(headers))
+	for i := range separators {
+		separators[i] = "---"
+	}
+	writeRow(b, separators)
+	for _, row := range rows {
+		writeRow(b, row)
+	}
+	b.WriteString("\n")
+}
+
+// writeRow writes one table row, escaping pipes and flattening line breaks so that cells cannot break the table.
+func writeRow(b *strings.Builder, cells []string) {
+	escaped := make([]string, len(cells))
+	for i, cell := range cells {
+		escaped[i] = strings.NewReplacer("|", `\|`, "\r\n", " ", "\n", " ").Replace(cell)
+	}
+	b.WriteString("| " + strings.Join(escaped, " | ") + " |\n")
+}
@@ -0,0 +1,12 @@
+package output
+
+// PathData holds the values available to output path templates.
+type PathData struct {
+	Name   string // source file name without extension
+	Source string // source file name
+	Date   string // report date, 2006-01-02
+	Time   string // report time, 150405
+	ID     string // stored report ID
+	Format Format
+	Ext    string // file extension without the dot
+}
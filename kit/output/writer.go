@@ -0,0 +1,197 @@
+package output
+
+import (
+	"bytes"
+	"data-insights/kit/chart"
+	"data-insights/kit/common"
+	"data-insights/kit/email"
+	"data-insights/kit/file"
+	"data-insights/kit/report"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	textTemplate "text/template"
+	"time"
+)
+
+type Writer struct {
+	Formats      []Format
+	PathTemplate *textTemplate.Template
+	Renderer     email.Renderer // renders the HTML format
+
+	mu      sync.Mutex
+	written map[string]string // report ID by path, of the files written so far
+}
+
+// ParseFormats parses a comma-separated list of output formats, e.g. "html,markdown".
+func ParseFormats(value string) ([]Format, error) {
+	var formats []Format
+	for _, name := range strings.Split(value, ",") {
+		format := Format(strings.TrimSpace(strings.ToLower(name)))
+		if format == "" {
+			continue
+		}
+		if _, ok := extensions[format]; !ok {
+			return nil, fmt.Errorf("unknown output format: %s", format)
+		}
+		formats = append(formats, format)
+	}
+	return formats, nil
+}
+
+// NewWriter creates a writer for the given formats. The path template is a text/template over PathData and
+// defaults to DefaultPathTemplate; it must give every format and every report its own path.
+func NewWriter(formats []Format, pathTemplate string, renderer email.Renderer) (*Writer, error) {
+	if pathTemplate == "" {
+		pathTemplate = DefaultPathTemplate
+	}
+	tmpl, err := textTemplate.New("path").Option("missingkey=error").Parse(pathTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid output path template: %v", err)
+	}
+	w := &Writer{Formats: formats, PathTemplate: tmpl, Renderer: renderer, written: make(map[string]string)}
+
+	// Catch templates that would make the formats, or the reports of different files or of one file processed
+	// twice in a day, overwrite each other before any report is written
+	createdAt := time.Date(2024, 8, 12, 10, 15, 0, 0, time.UTC)
+	samples := []report.Report{
+		{ID: "data-20240812-101500-00000001", SourceFile: "data.json", CreatedAt: createdAt},
+		{ID: "other-20240812-101500-00000002", SourceFile: "other.json", CreatedAt: createdAt},
+		{ID: "data-20240812-121500-00000003", SourceFile: "data.json", CreatedAt: createdAt.Add(2 * time.Hour)},
+	}
+	type owner struct {
+		id     string
+		format Format
+	}
+	paths := make(map[string]owner)
+	for _, sample := range samples {
+		for _, format := range formats {
+			path, err := w.path(sample, format)
+			if err != nil {
+				return nil, err
+			}
+			other, ok := paths[path]
+			switch {
+			case ok && other.id == sample.ID:
+				return nil, fmt.Errorf("output path template gives the %s and %s formats the same path; use {{.Ext}} or {{.Format}}", other.format, format)
+			case ok:
+				return nil, fmt.Errorf("output path template gives different reports the same path; use {{.ID}} or {{.Time}}")
+			}
+			paths[path] = owner{id: sample.ID, format: format}
+		}
+	}
+	return w, nil
+}
+
+// Write renders the report in every format and writes it to its templated path, creating directories as
+// needed. Returns the paths of the written reports, and an error rather than overwriting the file of another
+// report written by the writer, such as that of a file with the same name processed in the same second.
+func (w *Writer) Write(r report.Report, charts []chart.Chart) ([]string, error) {
+	var written []string
+	for _, format := range w.Formats {
+		path, err := w.path(r, format)
+		if err != nil {
+			return written, err
+		}
+		if err := w.claim(path, r.ID); err != nil {
+			return written, err
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return written, fmt.Errorf("failed to create output directory: %v", err)
+		}
+
+		var content []byte
+		switch format {
+		case FormatHTML:
+			content, err = w.renderHTML(r, charts)
+		case FormatMarkdown:
+			var chartLinks map[common.Section]string
+			if chartLinks, err = writeCharts(path, charts); err == nil {
+				content = []byte(RenderMarkdown(r, chartLinks))
+			}
+		case FormatJSON:
+			content, err = json.MarshalIndent(r, "", "  ")
+		}
+		if err != nil {
+			return written, fmt.Errorf("failed to render %s report: %v", format, err)
+		}
+
+		if err := os.WriteFile(path, content, 0o644); err != nil {
+			return written, fmt.Errorf("failed to write %s report: %v", format, err)
+		}
+		written = append(written, path)
+	}
+	return written, nil
+}
+
+// claim reserves the path for the report, and returns an error if another report was written to it.
+func (w *Writer) claim(path, id string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if other, ok := w.written[path]; ok && other != id {
+		return fmt.Errorf("report %s would overwrite %s of report %s; use {{.ID}} in the output path template", id, path, other)
+	}
+	w.written[path] = id
+	return nil
+}
+
+// renderHTML renders the email template as a standalone document with the charts embedded as data URIs.
+func (w *Writer) renderHTML(r report.Report, charts []chart.Chart) ([]byte, error) {
+	chartURLs := make(map[common.Section]template.URL)
+	for _, c := range charts {
+		chartURLs[c.Section] = c.DataURI()
+	}
+	body, err := w.Renderer.Render(common.EmailData{
+		UserMetricsWithInsights: r.Insights,
+		ToolCalls:               r.ToolCalls,
+		Charts:                  chartURLs,
+		Standalone:              true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return []byte(body), nil
+}
+
+func (w *Writer) path(r report.Report, format Format) (string, error) {
+	var path bytes.Buffer
+	err := w.PathTemplate.Execute(&path, PathData{
+		Name:   file.Name(r.SourceFile),
+		Source: file.BaseName(r.SourceFile),
+		Date:   r.CreatedAt.Format("2006-01-02"),
+		Time:   r.CreatedAt.Format("150405"),
+		ID:     r.ID,
+		Format: format,
+		Ext:    extensions[format],
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to execute output path template: %v", err)
+	}
+	return filepath.Clean(path.String()), nil
+}
+
+// writeCharts writes the chart images to a directory next to the Markdown report and returns their links,
+// relative to the report.
+func writeCharts(reportPath string, charts []chart.Chart) (map[common.Section]string, error) {
+	if len(charts) == 0 {
+		return nil, nil
+	}
+	dirName := strings.TrimSuffix(filepath.Base(reportPath), filepath.Ext(reportPath)) + ChartsDirSuffix
+	dir := filepath.Join(filepath.Dir(reportPath), dirName)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create charts directory: %v", err)
+	}
+
+	links := make(map[common.Section]string)
+	for _, c := range charts {
+		if err := os.WriteFile(filepath.Join(dir, c.Filename()), c.Data, 0o644); err != nil {
+			return nil, fmt.Errorf("failed to write chart: %v", err)
+		}
+		links[c.Section] = dirName + "/" + c.Filename()
+	}
+	return links, nil
+}
@@ -0,0 +1,26 @@
+package pdf
+
+const (
+	ContentType = "application/pdf"
+	Extension   = ".pdf"
+	Title       = "Website Insights Report"
+
+	PageMargin    = 15.0 // mm
+	ChartWidth    = 140.0
+	LineHeight    = 5.0
+	CellPadding   = 1.5
+	FontFamily    = "Helvetica"
+	BodyFontSize  = 10.0
+	SmallFontSize = 8.0
+	TitleFontSize = 18.0
+	H2FontSize    = 14.0
+	H3FontSize    = 12.0
+)
+
+// Colours are RGB triples matching the email template.
+var (
+	TextColour       = [3]int{0x33, 0x33, 0x33}
+	MutedColour      = [3]int{0x55, 0x55, 0x55}
+	BorderColour     = [3]int{0xdd, 0xdd, 0xdd}
+	HeaderFillColour = [3]int{0xf2, 0xf2, 0xf2}
+)
@@ -0,0 +1,203 @@
+package pdf
+
+import (
+	"bytes"
+	"data-insights/kit/chart"
+	"data-insights/kit/common"
+	"data-insights/kit/report"
+	"fmt"
+	"github.com/go-pdf/fpdf"
+	"path/filepath"
+	"strconv"
+)
+
+// Render lays out the report as an A4 PDF with the same content as the email template: the executive summary and
+// recommendations, then every metrics section with its insight, chart and table, and the data queries appendix.
+// Only the given sections are rendered, or all when none are given. Charts must be PNG; other formats are skipped.
+func Render(r report.Report, sections []common.Section, charts []chart.Chart) ([]byte, error) {
+	w := newWriter()
+	w.doc.SetTitle(Title, true)
+	w.doc.SetCreator("data-insights", true)
+	w.doc.AddPage()
+
+	w.heading(Title, TitleFontSize)
+	w.note(fmt.Sprintf("Insights from %s, generated on %s", filepath.Base(r.SourceFile), r.CreatedAt.Format("2 January 2006 15:04 MST")))
+
+	summary := r.Insights.ExecutiveSummary
+	if common.IncludesSection(sections, common.SUMMARYSECTION) && (summary.Summary != "" || len(summary.Recommendations) > 0) {
+		w.heading("Executive Summary", H2FontSize)
+		w.paragraph(summary.Summary)
+		if len(summary.Recommendations) > 0 {
+			w.heading("Recommendations", H3FontSize)
+			rows := make([][]string, 0, len(summary.Recommendations))
+			for _, recommendation := range summary.Recommendations {
+				rows = append(rows, []string{
+					strconv.Itoa(recommendation.Rank),
+					recommendation.Action,
+					recommendation.ExpectedImpact,
+					recommendation.Confidence,
+					report.FormatEvidence(recommendation.Evidence),
+				})
+			}
+			w.table([]string{"#", "Recommendation", "Expected Impact", "Confidence", "Evidence"}, []float64{0.05, 0.37, 0.16, 0.15, 0.27}, rows)
+		}
+	}
+
+	chartsBySection := make(map[common.Section]chart.Chart)
+	for _, c := range charts {
+		if c.Format == chart.FormatPNG {
+			chartsBySection[c.Section] = c
+		}
+	}
+	for _, section := range report.MetricSections(r.Insights, sections) {
+		w.heading(section.Title, H2FontSize)
+		w.paragraph(section.Insight)
+		if c, ok := chartsBySection[section.Section]; ok {
+			w.image(c)
+		}
+		rows := make([][]string, 0, len(section.Rows))
+		for _, row := range section.Rows {
+			rows = append(rows, []string{row.Name, row.Value})
+		}
+		w.table([]string{section.NameHeader, section.ValueHeader}, []float64{0.6, 0.4}, rows)
+	}
+
+	if len(r.ToolCalls) > 0 {
+		w.heading("Appendix: Data Queries", H2FontSize)
+		w.paragraph("The following queries were run against the raw data while the insights were written:")
+		rows := make([][]string, 0, len(r.ToolCalls))
+		for _, call := range r.ToolCalls {
+			rows = append(rows, []string{call.Name, call.Arguments})
+		}
+		w.table([]string{"Query", "Arguments"}, []float64{0.3, 0.7}, rows)
+	}
+
+	var buf bytes.Buffer
+	if err := w.doc.Output(&buf); err != nil {
+		return nil, fmt.Errorf("failed to write pdf: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// writer wraps the PDF document with the few layout primitives the report needs. The core fonts only cover
+// Windows-1252, so all text goes through the document's UTF-8 translator.
+type writer struct {
+	doc       *fpdf.Fpdf
+	translate func(string) string
+}
+
+func newWriter() *writer {
+	doc := fpdf.New("P", "mm", "A4", "")
+	doc.SetMargins(PageMargin, PageMargin, PageMargin)
+	doc.SetAutoPageBreak(true, PageMargin)
+	doc.SetTextColor(TextColour[0], TextColour[1], TextColour[2])
+	doc.SetDrawColor(BorderColour[0], BorderColour[1], BorderColour[2])
+	return &writer{doc: doc, translate: doc.UnicodeTranslatorFromDescriptor("")}
+}
+
+func (w *writer) contentWidth() float64 {
+	pageWidth, _ := w.doc.GetPageSize()
+	left, _, right, _ := w.doc.GetMargins()
+	return pageWidth - left - right
+}
+
+// ensureSpace starts a new page unless height millimetres fit above the bottom margin.
+func (w *writer) ensureSpace(height float64) {
+	_, pageHeight := w.doc.GetPageSize()
+	_, _, _, bottom := w.doc.GetMargins()
+	if w.doc.GetY()+height > pageHeight-bottom {
+		w.doc.AddPage()
+	}
+}
+
+func (w *writer) heading(text string, size float64) {
+	// Keep headings with at least a few lines of what follows them
+	w.ensureSpace(size/2 + 4*LineHeight)
+	w.doc.Ln(size / 3)
+	w.doc.SetFont(FontFamily, "B", size)
+	w.doc.MultiCell(0, size/2, w.translate(text), "", "L", false)
+	w.doc.Ln(1)
+}
+
+func (w *writer) paragraph(text string) {
+	if text == "" {
+		return
+	}
+	w.doc.SetFont(FontFamily, "", BodyFontSize)
+	w.doc.SetTextColor(MutedColour[0], MutedColour[1], MutedColour[2])
+	w.doc.MultiCell(0, LineHeight, w.translate(text), "", "L", false)
+	w.doc.SetTextColor(TextColour[0], TextColour[1], TextColour[2])
+	w.doc.Ln(2)
+}
+
+func (w *writer) note(text string) {
+	w.doc.SetFont(FontFamily, "I", SmallFontSize)
+	w.doc.SetTextColor(MutedColour[0], MutedColour[1], MutedColour[2])
+	w.doc.MultiCell(0, LineHeight, w.translate(text), "", "L", false)
+	w.doc.SetTextColor(TextColour[0], TextColour[1], TextColour[2])
+}
+
+// image draws the chart at ChartWidth, keeping its aspect ratio.
+func (w *writer) image(c chart.Chart) {
+	options := fpdf.ImageOptions{ImageType: "PNG"}
+	info := w.doc.RegisterImageOptionsReader(string(c.Section), options, bytes.NewReader(c.Data))
+	if info == nil || w.doc.Err() {
+		// A broken chart should not lose the rest of the report
+		w.doc.ClearError()
+		return
+	}
+	height := ChartWidth * info.Height() / info.Width()
+	w.ensureSpace(height + 2)
+	w.doc.ImageOptions(string(c.Section), w.doc.GetX(), w.doc.GetY(), ChartWidth, height, true, options, 0, "")
+	w.doc.Ln(2)
+}
+
+// table draws a bordered table with a shaded header row. Column widths are fractions of the content width; cells
+// wrap, and rows that do not fit start a new page with the header repeated.
+func (w *writer) table(headers []string, widths []float64, rows [][]string) {
+	columns := make([]float64, len(widths))
+	for i, fraction := range widths {
+		columns[i] = w.contentWidth() * fraction
+	}
+
+	w.doc.SetFillColor(HeaderFillColour[0], HeaderFillColour[1], HeaderFillColour[2])
+	w.row(headers, columns, "B", true)
+	for _, row := range rows {
+		_, pageHeight := w.doc.GetPageSize()
+		_, _, _, bottom := w.doc.GetMargins()
+		if w.doc.GetY()+w.rowHeight(row, columns, "") > pageHeight-bottom {
+			w.doc.AddPage()
+			w.row(headers, columns, "B", true)
+		}
+		w.row(row, columns, "", false)
+	}
+	w.doc.Ln(3)
+}
+
+func (w *writer) rowHeight(cells []string, columns []float64, style string) float64 {
+	w.doc.SetFont(FontFamily, style, BodyFontSize)
+	lines := 1
+	for i, cell := range cells {
+		if n := len(w.doc.SplitText(w.translate(cell), columns[i]-2*CellPadding)); n > lines {
+			lines = n
+		}
+	}
+	return float64(lines)*LineHeight + 2*CellPadding
+}
+
+func (w *writer) row(cells []string, columns []float64, style string, fill bool) {
+	height := w.rowHeight(cells, columns, style)
+	x, y := w.doc.GetX(), w.doc.GetY()
+	fillStyle := "D"
+	if fill {
+		fillStyle = "FD"
+	}
+	for i, cell := range cells {
+		w.doc.Rect(x, y, columns[i], height, fillStyle)
+		w.doc.SetXY(x+CellPadding, y+CellPadding)
+		w.doc.MultiCell(columns[i]-2*CellPadding, LineHeight, w.translate(cell), "", "L", false)
+		x += columns[i]
+	}
+	left, _, _, _ := w.doc.GetMargins()
+	w.doc.SetXY(left, y+height)
+}
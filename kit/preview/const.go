@@ -0,0 +1,21 @@
+package preview
+
+import "time"
+
+const (
+	DefaultAddr        = "localhost:8080"
+	VersionPath        = "/__version"
+	ReloadPollInterval = time.Second
+)
+
+// reloadScript polls the version endpoint and reloads the page when the templates, theme or insights change.
+const reloadScript = `<script>
+(function () {
+  var version = %q;
+  setInterval(function () {
+    fetch(%q).then(function (r) { return r.text(); }).then(function (v) {
+      if (v !== version) { location.reload(); }
+    }).catch(function () {});
+  }, %d);
+})();
+</script>`
@@ -0,0 +1,156 @@
+package preview
+
+import (
+	"data-insights/kit/chart"
+	"data-insights/kit/common"
+	"data-insights/kit/email"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Server serves the rendered report for template development. The templates, theme and insights file are read
+// again on every request, so that edits show up on reload, and every page polls for changes to reload itself.
+type Server struct {
+	TemplateDir  string
+	ThemeFile    string
+	InsightsFile string
+	Insights     common.UserMetricsWithInsights
+	ToolCalls    []common.ToolCallLog
+	Charts       []chart.Chart
+}
+
+// NewServer returns a server that renders the saved UserMetricsWithInsights JSON in insightsFile.
+func NewServer(templateDir, themeFile, insightsFile string) *Server {
+	return &Server{TemplateDir: templateDir, ThemeFile: themeFile, InsightsFile: insightsFile}
+}
+
+// NewReportServer returns a server that renders fixed insights, such as those of a stored report, with charts.
+func NewReportServer(templateDir, themeFile string, insights common.UserMetricsWithInsights, toolCalls []common.ToolCallLog, charts []chart.Chart) *Server {
+	return &Server{TemplateDir: templateDir, ThemeFile: themeFile, Insights: insights, ToolCalls: toolCalls, Charts: charts}
+}
+
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", s.serveReport)
+	mux.HandleFunc(VersionPath, s.serveVersion)
+	return mux
+}
+
+// serveReport renders the report. The recipient, sections and standalone query parameters set the matching
+// email data fields. Errors are shown in the page, which keeps polling so that fixing the template reloads it.
+func (s *Server) serveReport(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+
+	body, err := s.render(r)
+	if err != nil {
+		log.Printf("Preview failed: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		body = fmt.Sprintf("<pre>%s</pre>", template.HTMLEscapeString(err.Error()))
+	}
+	fmt.Fprint(w, injectReload(body, s.version()))
+}
+
+func (s *Server) serveVersion(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	fmt.Fprint(w, s.version())
+}
+
+func (s *Server) render(r *http.Request) (string, error) {
+	theme := email.DefaultTheme()
+	if s.ThemeFile != "" {
+		var err error
+		if theme, err = email.LoadTheme(s.ThemeFile); err != nil {
+			return "", err
+		}
+	}
+	renderer, err := email.NewRenderer(s.TemplateDir, theme)
+	if err != nil {
+		return "", err
+	}
+
+	insights := s.Insights
+	if s.InsightsFile != "" {
+		if insights, err = loadInsights(s.InsightsFile); err != nil {
+			return "", err
+		}
+	}
+
+	query := r.URL.Query()
+	var sections []common.Section
+	if value := query.Get("sections"); value != "" {
+		for _, name := range strings.Split(value, ",") {
+			section := common.Section(strings.TrimSpace(name))
+			if !common.IsValidSection(section) {
+				return "", fmt.Errorf("unknown section: %s", section)
+			}
+			sections = append(sections, section)
+		}
+	}
+	charts := make(map[common.Section]template.URL)
+	for _, c := range s.Charts {
+		charts[c.Section] = c.DataURI()
+	}
+
+	return renderer.Render(common.EmailData{
+		RecipientName:           query.Get("recipient"),
+		UserMetricsWithInsights: insights,
+		ToolCalls:               s.ToolCalls,
+		Sections:                sections,
+		Charts:                  charts,
+		Standalone:              query.Get("standalone") == "true",
+	})
+}
+
+// version returns the latest modification time of the files the preview is rendered from.
+func (s *Server) version() string {
+	paths, _ := filepath.Glob(filepath.Join(s.TemplateDir, "*.html"))
+	partials, _ := filepath.Glob(filepath.Join(s.TemplateDir, email.PartialsDir, "*.html"))
+	paths = append(paths, partials...)
+	for _, path := range []string{s.ThemeFile, s.InsightsFile} {
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+
+	var latest int64
+	for _, path := range paths {
+		if info, err := os.Stat(path); err == nil && info.ModTime().UnixNano() > latest {
+			latest = info.ModTime().UnixNano()
+		}
+	}
+	// The file count catches deleted templates, which do not change any remaining modification time
+	return fmt.Sprintf("%d-%d", latest, len(paths))
+}
+
+// injectReload adds the reload script before the closing body tag, or at the end of the page if there is none.
+func injectReload(body, version string) string {
+	script := fmt.Sprintf(reloadScript, version, VersionPath, ReloadPollInterval.Milliseconds())
+	if i := strings.LastIndex(body, "</body>"); i >= 0 {
+		return body[:i] + script + body[i:]
+	}
+	return body + script
+}
+
+func loadInsights(path string) (common.UserMetricsWithInsights, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return common.UserMetricsWithInsights{}, fmt.Errorf("failed to read insights file: %v", err)
+	}
+	var insights common.UserMetricsWithInsights
+	if err := json.Unmarshal(content, &insights); err != nil {
+		return common.UserMetricsWithInsights{}, fmt.Errorf("failed to unmarshal insights file: %v", err)
+	}
+	return insights, nil
+}
@@ -0,0 +1,7 @@
+package report
+
+const (
+	DefaultDir      = "reports"
+	fileExtension   = ".json"
+	timestampFormat = "20060102-150405"
+)
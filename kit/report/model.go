@@ -0,0 +1,34 @@
+package report
+
+import (
+	"data-insights/kit/common"
+	"time"
+)
+
+// Report is the stored record of one processed file. It keeps the computed metrics next to the insights so that
+// follow-up questions can be answered without recomputing anything.
+type Report struct {
+	ID         string                         `json:"id"`
+	SourceFile string                         `json:"source_file"`
+	SourceHash string                         `json:"source_hash,omitempty"` // SHA-256 of the data the report was built from
+	CreatedAt  time.Time                      `json:"created_at"`
+	Model      string                         `json:"model,omitempty"` // the model, or "rules", that wrote the insights
+	Metrics    common.UserMetrics             `json:"metrics"`
+	Insights   common.UserMetricsWithInsights `json:"insights"`
+	ToolCalls  []common.ToolCallLog           `json:"tool_calls,omitempty"`
+}
+
+// Section is a format-neutral view of one metrics section of the report: its insight and a two-column table.
+type Section struct {
+	Section     common.Section
+	Title       string
+	Insight     string
+	NameHeader  string
+	ValueHeader string
+	Rows        []Row
+}
+
+type Row struct {
+	Name  string
+	Value string
+}
@@ -0,0 +1,94 @@
+package report
+
+import (
+	"data-insights/kit/common"
+	"fmt"
+	"strings"
+)
+
+// MetricSections returns the metrics sections of the insights that are selected, in report order, with the same
+// titles and columns as the email template. All sections are returned when none are selected. The executive
+// summary is not a metrics section and is rendered separately by every format.
+func MetricSections(insights common.UserMetricsWithInsights, selected []common.Section) []Section {
+	overall := insights.OverallMetrics
+	all := []Section{
+		{
+			Section:     common.OVERALLSECTION,
+			Title:       "Overall Metrics",
+			Insight:     overall.AIInsight,
+			NameHeader:  "Metric",
+			ValueHeader: "Value",
+			Rows: []Row{
+				{"Overall Engagement Rate", overall.OverallEngagementRate},
+				{"Average Session Duration", overall.AverageSessionDuration},
+				{"Bounce Rate", overall.BounceRate},
+				{"Pages Per Session", overall.PagesPerSession},
+				{"New User Percentage", overall.NewUserPercentage},
+				{"Session Per User", overall.SessionPerUser},
+			},
+		},
+		aggregatedSection(common.TOPCOUNTRIESSECTION, "Top 5 Countries with Highest Engagement Rate", "Country", "Engagement Rate", insights.Top5CountriesWithHighestEngagementRate, engagementRate),
+		aggregatedSection(common.BOTTOMCOUNTRIESSECTION, "Top 5 Countries with Lowest Engagement Rate", "Country", "Engagement Rate", insights.Top5CountriesWithLowestEngagementRate, engagementRate),
+		aggregatedSection(common.BOUNCERATESSECTION, "Bounce Rates by Devices", "Device", "Bounce Rate", insights.BounceRatesByDevices, bounceRate),
+		aggregatedSection(common.TOPPAGESSECTION, "Top 5 Pages with Highest Number of Sessions", "Page", "Sessions", insights.Top5PagesWithHighestNoOfSessions, totalSessions),
+		aggregatedSection(common.BOTTOMPAGESSECTION, "Top 5 Pages with Lowest Number of Sessions", "Page", "Sessions", insights.Top5PagesWithLowestNoOfSessions, totalSessions),
+		aggregatedSection(common.SESSIONDURATIONSSECTION, "Average Session Durations by Devices", "Device", "Average Session Duration", insights.AverageSessionDurationsByDevices, sessionDuration),
+	}
+
+	var sections []Section
+	for _, section := range all {
+		if common.IncludesSection(selected, section.Section) {
+			sections = append(sections, section)
+		}
+	}
+	return sections
+}
+
+// FormatRecommendation returns a one-line description of the recommendation with its verified evidence.
+func FormatRecommendation(recommendation common.Recommendation) string {
+	line := fmt.Sprintf("%d. %s", recommendation.Rank, sentence(recommendation.Action))
+	if recommendation.ExpectedImpact != "" {
+		line += " Expected impact: " + sentence(recommendation.ExpectedImpact)
+	}
+	if recommendation.Confidence != "" {
+		line += " Confidence: " + sentence(recommendation.Confidence)
+	}
+	if evidence := FormatEvidence(recommendation.Evidence); evidence != "" {
+		line += " Evidence: " + evidence + "."
+	}
+	return line
+}
+
+// FormatEvidence returns the verified evidence as a comma-separated list, e.g. "mobile bounce_rate: 46.80%".
+func FormatEvidence(evidence []common.Evidence) string {
+	var items []string
+	for _, e := range evidence {
+		if !e.Verified {
+			continue
+		}
+		item := e.Metric + ": " + e.Value
+		if e.Name != "" {
+			item = e.Name + " " + item
+		}
+		items = append(items, item)
+	}
+	return strings.Join(items, ", ")
+}
+
+func aggregatedSection(section common.Section, title, nameHeader, valueHeader string, metrics common.AggregatedMetricsWithInsight, value func(common.AggregatedMetric) string) Section {
+	rows := make([]Row, 0, len(metrics.AggregatedMetrics))
+	for _, metric := range metrics.AggregatedMetrics {
+		rows = append(rows, Row{metric.Name, value(metric)})
+	}
+	return Section{Section: section, Title: title, Insight: metrics.AIInsight, NameHeader: nameHeader, ValueHeader: valueHeader, Rows: rows}
+}
+
+func engagementRate(metric common.AggregatedMetric) string  { return metric.AverageEngagementRate }
+func bounceRate(metric common.AggregatedMetric) string      { return metric.BounceRate }
+func totalSessions(metric common.AggregatedMetric) string   { return metric.TotalSessions }
+func sessionDuration(metric common.AggregatedMetric) string { return metric.AverageSessionDuration }
+
+// sentence trims the text and ends it with a full stop.
+func sentence(text string) string {
+	return strings.TrimSuffix(strings.TrimSpace(text), ".") + "."
+}
@@ -0,0 +1,117 @@
+package report
+
+import (
+	"crypto/rand"
+	"data-insights/kit/common"
+	"data-insights/kit/file"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+type Store struct {
+	dir string
+}
+
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// NewReport creates a report for the given source file with an ID made of the file name, the creation time and
+// random bytes, so that reports of files with the same name, or of one file processed twice within a second, do
+// not overwrite each other.
+func NewReport(sourceFile string, metrics common.UserMetrics, insights common.UserMetricsWithInsights, toolCalls []common.ToolCallLog) Report {
+	createdAt := time.Now().UTC()
+	name := file.Name(sourceFile)
+	random := make([]byte, 4)
+	_, _ = rand.Read(random)
+	return Report{
+		ID:         fmt.Sprintf("%s-%s-%s", name, createdAt.Format(timestampFormat), hex.EncodeToString(random)),
+		SourceFile: sourceFile,
+		CreatedAt:  createdAt,
+		Metrics:    metrics,
+		Insights:   insights,
+		ToolCalls:  toolCalls,
+	}
+}
+
+// SourceData reads the raw data of the report from its source file. Returns an error if the file has changed since
+// the report was created, since answers and charts from it would no longer match the report. Reports stored before
+// the hash was recorded are not checked.
+func (r Report) SourceData(source file.Source) ([]common.Insight, error) {
+	data, hash, err := file.GetRawDataWithHash(source, r.SourceFile)
+	if err != nil {
+		return nil, err
+	}
+	if r.SourceHash != "" && hash != r.SourceHash {
+		return nil, fmt.Errorf("source file %s has changed since report %s was created", r.SourceFile, r.ID)
+	}
+	return data, nil
+}
+
+// Save writes the report to the store directory as <id>.json, creating the directory if needed.
+func (s *Store) Save(report Report) error {
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create report directory: %v", err)
+	}
+	content, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal report: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(s.dir, report.ID+fileExtension), content, 0o644); err != nil {
+		return fmt.Errorf("failed to write report: %v", err)
+	}
+	return nil
+}
+
+// Load reads the report with the given ID.
+func (s *Store) Load(id string) (Report, error) {
+	content, err := os.ReadFile(filepath.Join(s.dir, id+fileExtension))
+	if err != nil {
+		return Report{}, fmt.Errorf("failed to read report %s: %v", id, err)
+	}
+	var report Report
+	if err := json.Unmarshal(content, &report); err != nil {
+		return Report{}, fmt.Errorf("failed to unmarshal report %s: %v", id, err)
+	}
+	return report, nil
+}
+
+// Latest returns the most recently created report in the store.
+func (s *Store) Latest() (Report, error) {
+	reports, err := s.List()
+	if err != nil {
+		return Report{}, err
+	}
+	if len(reports) == 0 {
+		return Report{}, fmt.Errorf("no reports found in %s", s.dir)
+	}
+	return reports[len(reports)-1], nil
+}
+
+// List returns every report in the store, oldest first. Reports that cannot be read are logged and skipped, so
+// that one corrupt file does not hide the others.
+func (s *Store) List() ([]Report, error) {
+	paths, err := filepath.Glob(filepath.Join(s.dir, "*"+fileExtension))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reports: %v", err)
+	}
+
+	var reports []Report
+	for _, path := range paths {
+		report, err := s.Load(strings.TrimSuffix(filepath.Base(path), fileExtension))
+		if err != nil {
+			log.Printf("Skipping %s: %v", path, err)
+			continue
+		}
+		reports = append(reports, report)
+	}
+	sort.Slice(reports, func(i, j int) bool { return reports[i].CreatedAt.Before(reports[j].CreatedAt) })
+	return reports, nil
+}
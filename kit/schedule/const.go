@@ -0,0 +1,35 @@
+package schedule
+
+import "time"
+
+const (
+	DefaultJobsFile  = "schedules.json"
+	DefaultStateFile = "schedule-state.json"
+
+	// maxWait is the longest the scheduler sleeps before checking the clock again, so that clock changes and
+	// system suspends delay a run by at most this long.
+	maxWait = time.Minute
+
+	// searchYears bounds the search for the next run time of expressions that never match, such as "0 0 30 2 *".
+	searchYears = 5
+)
+
+// macros are the shorthands for common schedules.
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var monthNames = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+var dayNames = map[string]int{
+	"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+}
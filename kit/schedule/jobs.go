@@ -0,0 +1,50 @@
+package schedule
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// LoadJobs reads and validates a jobs file.
+func LoadJobs(path string) (JobsConfig, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return JobsConfig{}, fmt.Errorf("failed to read jobs file: %v", err)
+	}
+	var config JobsConfig
+	if err := json.Unmarshal(content, &config); err != nil {
+		return JobsConfig{}, fmt.Errorf("failed to unmarshal jobs file: %v", err)
+	}
+	if err := config.Validate(); err != nil {
+		return JobsConfig{}, err
+	}
+	return config, nil
+}
+
+// Validate checks that there is at least one job, and that every job has a unique name and a valid schedule that
+// runs.
+func (c JobsConfig) Validate() error {
+	if len(c.Jobs) == 0 {
+		return fmt.Errorf("no jobs configured")
+	}
+	names := make(map[string]bool, len(c.Jobs))
+	for i, job := range c.Jobs {
+		if job.Name == "" {
+			return fmt.Errorf("job %d has no name", i+1)
+		}
+		if names[job.Name] {
+			return fmt.Errorf("job %s is configured more than once", job.Name)
+		}
+		names[job.Name] = true
+		schedule, err := Parse(job.Schedule)
+		if err != nil {
+			return fmt.Errorf("job %s: %v", job.Name, err)
+		}
+		if schedule.Next(time.Now()).IsZero() {
+			return fmt.Errorf("job %s: the schedule %q never runs", job.Name, job.Schedule)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package schedule
+
+import "time"
+
+// Job is a scheduled run of the pipeline with its own config file.
+type Job struct {
+	Name     string `json:"name"`
+	Schedule string `json:"schedule"`         // cron expression, e.g. "0 8 * * 1" for Mondays at 08:00
+	Config   string `json:"config,omitempty"` // config file of the run, CONFIG_FILE or .env when empty
+}
+
+type JobsConfig struct {
+	Jobs []Job `json:"jobs"`
+}
+
+// Schedule is a parsed cron expression. Every field is a bit set of the values it matches.
+type Schedule struct {
+	minute, hour, dayOfMonth, month, dayOfWeek uint64
+	// When both the day of the month and the day of the week are restricted, a day matches either, as in cron.
+	dayOfMonthRestricted, dayOfWeekRestricted bool
+}
+
+// StateFile is the persisted state of the scheduler: the time each job last started.
+type StateFile struct {
+	LastRuns map[string]time.Time `json:"last_runs"`
+}
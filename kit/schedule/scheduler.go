@@ -0,0 +1,119 @@
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+)
+
+// Scheduler runs jobs on their schedules, one at a time, so that runs never overlap.
+type Scheduler struct {
+	Jobs      []Job
+	State     *State
+	Run       func(Job) error
+	schedules map[string]Schedule
+	now       func() time.Time
+}
+
+func NewScheduler(jobs []Job, state *State, run func(Job) error) (*Scheduler, error) {
+	schedules := make(map[string]Schedule, len(jobs))
+	for _, job := range jobs {
+		schedule, err := Parse(job.Schedule)
+		if err != nil {
+			return nil, fmt.Errorf("job %s: %v", job.Name, err)
+		}
+		schedules[job.Name] = schedule
+	}
+	return &Scheduler{Jobs: jobs, State: state, Run: run, schedules: schedules, now: time.Now}, nil
+}
+
+// Start runs the jobs until the context is cancelled, and returns once the running job, if any, has finished.
+// A job is due at the first scheduled time after its last run, and runs once however many of its runs were missed
+// while the scheduler was stopped or busy. A job without a recorded run is scheduled from when it is first seen.
+// The last run is recorded when a run finishes, so a run interrupted by a crash is repeated after the restart.
+func (s *Scheduler) Start(ctx context.Context) error {
+	for _, job := range s.Jobs {
+		if _, ok := s.State.LastRun(job.Name); !ok {
+			if err := s.State.Record(job.Name, s.now()); err != nil {
+				return err
+			}
+		}
+		if next := s.nextRun(job); !next.After(s.now()) {
+			log.Printf("Job %s missed its run at %s and runs now", job.Name, next.Format(time.RFC1123))
+		} else {
+			log.Printf("Job %s scheduled, next run at %s", job.Name, next.Format(time.RFC1123))
+		}
+	}
+
+	for {
+		for _, job := range s.due(s.now()) {
+			if ctx.Err() != nil {
+				return nil
+			}
+			if err := s.runJob(job); err != nil {
+				return err
+			}
+		}
+
+		wait := maxWait
+		if next, ok := s.earliestRun(); ok && next.Sub(s.now()) < wait {
+			wait = next.Sub(s.now())
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+	}
+}
+
+// runJob runs the job and records the run, whether or not it succeeded. A failed run is logged and the job runs
+// again at its next scheduled time. Returns an error only if the state cannot be saved.
+func (s *Scheduler) runJob(job Job) error {
+	started := s.now()
+	log.Printf("Job %s started", job.Name)
+	if err := s.Run(job); err != nil {
+		log.Printf("Job %s failed after %s: %v", job.Name, time.Since(started).Round(time.Second), err)
+	} else {
+		log.Printf("Job %s finished in %s", job.Name, time.Since(started).Round(time.Second))
+	}
+	if err := s.State.Record(job.Name, started); err != nil {
+		return err
+	}
+	log.Printf("Job %s next run at %s", job.Name, s.nextRun(job).Format(time.RFC1123))
+	return nil
+}
+
+// due returns the jobs whose next run is at or before now, the longest overdue first.
+func (s *Scheduler) due(now time.Time) []Job {
+	var due []Job
+	for _, job := range s.Jobs {
+		if next := s.nextRun(job); !next.IsZero() && !next.After(now) {
+			due = append(due, job)
+		}
+	}
+	sort.SliceStable(due, func(i, j int) bool {
+		return s.nextRun(due[i]).Before(s.nextRun(due[j]))
+	})
+	return due
+}
+
+// earliestRun returns the next run of the job that runs first.
+func (s *Scheduler) earliestRun() (time.Time, bool) {
+	var earliest time.Time
+	for _, job := range s.Jobs {
+		if next := s.nextRun(job); !next.IsZero() && (earliest.IsZero() || next.Before(earliest)) {
+			earliest = next
+		}
+	}
+	return earliest, !earliest.IsZero()
+}
+
+func (s *Scheduler) nextRun(job Job) time.Time {
+	lastRun, _ := s.State.LastRun(job.Name)
+	return s.schedules[job.Name].Next(lastRun.Local())
+}
@@ -0,0 +1,67 @@
+package schedule
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// State records when each job last started, so that runs missed while the scheduler was stopped are caught up.
+type State struct {
+	Path     string
+	lastRuns map[string]time.Time
+}
+
+// LoadState reads the state file. A missing file is an empty state.
+func LoadState(path string) (*State, error) {
+	state := &State{Path: path, lastRuns: map[string]time.Time{}}
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state file: %v", err)
+	}
+	var file StateFile
+	if err := json.Unmarshal(content, &file); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal state file: %v", err)
+	}
+	for name, lastRun := range file.LastRuns {
+		state.lastRuns[name] = lastRun
+	}
+	return state, nil
+}
+
+// LastRun returns when the job last started, and whether it has a recorded run.
+func (s *State) LastRun(name string) (time.Time, bool) {
+	lastRun, ok := s.lastRuns[name]
+	return lastRun, ok
+}
+
+// Record sets the job's last run and saves the state.
+func (s *State) Record(name string, lastRun time.Time) error {
+	s.lastRuns[name] = lastRun
+	return s.save()
+}
+
+// save writes the state to a temporary file first, so that an interrupted write never corrupts it.
+func (s *State) save() error {
+	if dir := filepath.Dir(s.Path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("failed to create state directory: %v", err)
+		}
+	}
+	content, err := json.MarshalIndent(StateFile{LastRuns: s.lastRuns}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal state: %v", err)
+	}
+	if err := os.WriteFile(s.Path+".tmp", content, 0o644); err != nil {
+		return fmt.Errorf("failed to write state file: %v", err)
+	}
+	if err := os.Rename(s.Path+".tmp", s.Path); err != nil {
+		return fmt.Errorf("failed to write state file: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,9 @@
+package watch
+
+import "time"
+
+const (
+	DefaultInterval     = 10 * time.Second
+	DefaultStableFor    = 30 * time.Second
+	DefaultMarkerSuffix = ".done"
+)
@@ -0,0 +1,10 @@
+package watch
+
+import "time"
+
+// fileState is a version of a file, as seen by the watcher.
+type fileState struct {
+	size    int64
+	modTime time.Time
+	since   time.Time // when this version was first seen
+}
@@ -0,0 +1,123 @@
+package watch
+
+import (
+	"context"
+	"data-insights/kit/file"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// Watcher polls a directory for data files that are completely written, selected by Filter. A file is complete
+// when its marker file, the file's name followed by MarkerSuffix, exists, or when its size and modification time
+// have not changed for StableFor. Empty files are only complete with a marker. Marker files themselves are never
+// reported.
+type Watcher struct {
+	Dir          string
+	Filter       file.WalkOptions
+	Interval     time.Duration
+	StableFor    time.Duration
+	MarkerSuffix string
+	seen         map[string]fileState
+	reported     map[string]fileState
+	now          func() time.Time
+}
+
+func NewWatcher(dir string, filter file.WalkOptions, interval, stableFor time.Duration, markerSuffix string) *Watcher {
+	return &Watcher{
+		Dir:          dir,
+		Filter:       filter,
+		Interval:     interval,
+		StableFor:    stableFor,
+		MarkerSuffix: markerSuffix,
+		seen:         map[string]fileState{},
+		reported:     map[string]fileState{},
+		now:          time.Now,
+	}
+}
+
+// Watch calls handle with the files that have become complete since the last poll, every interval until the
+// context is cancelled. A failed poll, such as of a directory that is briefly unavailable, is logged and retried at
+// the next interval. handle is never called concurrently, and Watch returns once it has returned.
+func (w *Watcher) Watch(ctx context.Context, handle func([]string)) {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+	for {
+		ready, err := w.Poll()
+		if err != nil {
+			log.Printf("Error polling %s, retrying in %s: %v", w.Dir, w.Interval, err)
+		}
+		if len(ready) > 0 {
+			handle(ready)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll returns the files that have become complete since the last poll: new files, and files that have changed
+// since they were last reported.
+func (w *Watcher) Poll() ([]string, error) {
+	paths, err := file.FilePathWalkDir(w.Dir, w.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	now := w.now()
+	present := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		present[path] = true
+	}
+
+	var ready []string
+	seen := make(map[string]fileState, len(paths))
+	for _, path := range paths {
+		if w.MarkerSuffix != "" && strings.HasSuffix(path, w.MarkerSuffix) {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			// The file was removed or renamed since the walk
+			continue
+		}
+
+		state := fileState{size: info.Size(), modTime: info.ModTime(), since: now}
+		previous, unchanged := w.seen[path]
+		if unchanged = unchanged && previous.size == state.size && previous.modTime.Equal(state.modTime); unchanged {
+			state.since = previous.since
+		}
+		seen[path] = state
+
+		if reported, ok := w.reported[path]; ok && reported.size == state.size && reported.modTime.Equal(state.modTime) {
+			continue
+		}
+		// Marker files are looked up directly, since the filter may leave them out of the walk
+		marked := w.MarkerSuffix != "" && exists(path+w.MarkerSuffix)
+		// A file that was last modified long ago, such as one that was already there at startup, is stable once
+		// it has not changed between two polls
+		stable := state.size > 0 && (now.Sub(state.since) >= w.StableFor || unchanged && now.Sub(state.modTime) >= w.StableFor)
+		if marked || stable {
+			ready = append(ready, path)
+			w.reported[path] = state
+		}
+	}
+
+	// Forget removed files, so that a file with the same name is reported again
+	for path := range w.reported {
+		if !present[path] {
+			delete(w.reported, path)
+		}
+	}
+	w.seen = seen
+	return ready, nil
+}
+
+// exists reports whether a file exists at path.
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,161 @@
+package pkg
+
+import (
+	"data-insights/kit/common"
+	"data-insights/kit/config"
+	"data-insights/kit/email"
+	"data-insights/kit/notify"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// newTargets returns a notifier for every configured channel. Channels come from the channels file if one is
+// configured; otherwise email is always used, together with every channel whose webhook URL is set. In a dry run
+// the webhook notifiers write their payloads to the dry run directory instead of posting them.
+func newTargets(envVariables common.EnvVariables, renderer email.Renderer) ([]notify.Target, error) {
+	channels, err := loadChannels(envVariables)
+	if err != nil {
+		return nil, err
+	}
+
+	httpClient := &http.Client{}
+	var emailNotifier *notify.EmailNotifier
+	targets := make([]notify.Target, 0, len(channels.Channels))
+	for _, channel := range channels.Channels {
+		var notifier notify.Notifier
+		switch channel.Type {
+		case notify.ChannelEmail:
+			// Email channels share the SMTP service and recipients
+			if emailNotifier == nil {
+				if emailNotifier, err = newEmailNotifier(envVariables, renderer); err != nil {
+					return nil, err
+				}
+			}
+			notifier = emailNotifier
+		case notify.ChannelSlack:
+			notifier = notify.NewSlackNotifier(channel.URL, httpClient)
+			if envVariables.DryRun {
+				notifier = notify.NewFileNotifier(envVariables.DryRunDirectory, channel.Type)
+			}
+		case notify.ChannelTeams:
+			notifier = notify.NewTeamsNotifier(channel.URL, httpClient)
+			if envVariables.DryRun {
+				notifier = notify.NewFileNotifier(envVariables.DryRunDirectory, channel.Type)
+			}
+		case notify.ChannelWebhook:
+			notifier = notify.NewWebhookNotifier(channel.URL, httpClient)
+			if envVariables.DryRun {
+				notifier = notify.NewFileNotifier(envVariables.DryRunDirectory, channel.Type)
+			}
+		default:
+			return nil, fmt.Errorf("unknown channel type: %s", channel.Type)
+		}
+		targets = append(targets, notify.Target{Channel: channel, Notifier: notifier})
+	}
+	return targets, nil
+}
+
+// loadChannels returns the channels from the channels file if one is configured, then those of the config file,
+// or email plus the webhook channels set through environment variables otherwise.
+func loadChannels(envVariables common.EnvVariables) (notify.ChannelsConfig, error) {
+	if envVariables.ChannelsFile != "" {
+		return notify.LoadChannels(envVariables.ChannelsFile)
+	}
+	configuration, err := loadConfig(envVariables)
+	if err != nil {
+		return notify.ChannelsConfig{}, err
+	}
+	if len(configuration.Channels) > 0 {
+		return configuration.NotifyChannels()
+	}
+
+	config := notify.ChannelsConfig{Channels: []notify.Channel{{Type: notify.ChannelEmail}}}
+	webhooks := []notify.Channel{
+		{Type: notify.ChannelSlack, URL: envVariables.SlackWebhookURL},
+		{Type: notify.ChannelTeams, URL: envVariables.TeamsWebhookURL},
+		{Type: notify.ChannelWebhook, URL: envVariables.WebhookURL},
+	}
+	for _, channel := range webhooks {
+		if channel.URL != "" {
+			config.Channels = append(config.Channels, channel)
+		}
+	}
+	return config, config.Validate()
+}
+
+// newEmailNotifier creates the email service and loads the recipients for the email channel. Messages are queued
+// in the outbox and sent over SMTP, or written to the dry run directory in a dry run.
+func newEmailNotifier(envVariables common.EnvVariables, renderer email.Renderer) (*notify.EmailNotifier, error) {
+	if envVariables.DryRun {
+		if envVariables.EmailFrom == "" {
+			return nil, errors.New("EMAIL_FROM is required for the email channel")
+		}
+		recipients, err := loadRecipients(envVariables)
+		if err != nil {
+			return nil, fmt.Errorf("error loading recipients: %v", err)
+		}
+		emailService := email.NewFileEmailService(envVariables.DryRunDirectory, envVariables.EmailFrom)
+		return notify.NewEmailNotifier(emailService, renderer, recipients), nil
+	}
+
+	outbox, err := NewOutbox(envVariables)
+	if err != nil {
+		return nil, err
+	}
+
+	recipients, err := loadRecipients(envVariables)
+	if err != nil {
+		return nil, fmt.Errorf("error loading recipients: %v", err)
+	}
+
+	return notify.NewEmailNotifier(outbox, renderer, recipients), nil
+}
+
+// NewOutbox creates the SMTP email service behind the outbox that every email is queued in before it is sent.
+func NewOutbox(envVariables common.EnvVariables) (*email.Outbox, error) {
+	if envVariables.EmailFrom == "" || envVariables.SmtpHost == "" || envVariables.SmtpPort == "" {
+		return nil, errors.New("EMAIL_FROM, SMTP_HOST and SMTP_PORT are required for the email channel")
+	}
+	if envVariables.EmailPass == "" && email.SMTPAuth(envVariables.SmtpAuth) != email.AuthNone {
+		return nil, errors.New("EMAIL_FROM_PASS is required for the email channel unless SMTP_AUTH is none")
+	}
+
+	emailService, err := email.NewSMTPEmailService(envVariables.SmtpHost, envVariables.SmtpPort, envVariables.EmailFrom, envVariables.EmailPass, email.SMTPOptions{
+		Security: email.SMTPSecurity(envVariables.SmtpSecurity),
+		Auth:     email.SMTPAuth(envVariables.SmtpAuth),
+		CAFile:   envVariables.SmtpCAFile,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error creating email service: %v", err)
+	}
+
+	return email.NewOutbox(envVariables.OutboxDirectory, emailService, envVariables.OutboxMaxAttempts, envVariables.OutboxBackoff), nil
+}
+
+// loadRecipients returns the recipients from the recipients file if one is configured, then those of the config
+// file, or the single EMAIL_TO recipient otherwise.
+func loadRecipients(envVariables common.EnvVariables) (email.RecipientsConfig, error) {
+	if envVariables.RecipientsFile != "" {
+		return email.LoadRecipients(envVariables.RecipientsFile)
+	}
+	configuration, err := loadConfig(envVariables)
+	if err != nil {
+		return email.RecipientsConfig{}, err
+	}
+	if configuration.Recipients != nil {
+		return *configuration.Recipients, configuration.Recipients.Validate()
+	}
+	if envVariables.EmailTo == "" {
+		return email.RecipientsConfig{}, errors.New("EMAIL_TO or RECIPIENTS_FILE is required for the email channel")
+	}
+	return email.SingleRecipient(envVariables.RecipientName, envVariables.EmailTo), nil
+}
+
+// loadConfig loads the YAML or TOML config file, or returns an empty config if none is used.
+func loadConfig(envVariables common.EnvVariables) (config.Config, error) {
+	if !config.IsConfigFile(envVariables.ConfigFile) {
+		return config.Config{}, nil
+	}
+	return config.Load(envVariables.ConfigFile)
+}
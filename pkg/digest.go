@@ -0,0 +1,93 @@
+package pkg
+
+import (
+	"data-insights/kit/ai"
+	"data-insights/kit/common"
+	"data-insights/kit/file"
+	"data-insights/kit/notify"
+	"fmt"
+	"log"
+	"time"
+)
+
+// processDigest processes every file like processFile, up to CONCURRENCY files at a time, then delivers them
+// together as one digest with a cross-property summary and records them in the ledger. If any file fails no
+// partial digest is sent, unless CONTINUE_ON_ERROR is set, in which case the digest covers the files that
+// succeeded. Returns the result of every file by index.
+func (p *Pipeline) processDigest(files []string, hashes map[string]string) []FileResult {
+	results := make([]FileResult, len(files))
+	notifications := make([]notify.Notification, len(files))
+	models := make([]string, len(files))
+	forEachFile(files, p.envVariables.Concurrency, !p.envVariables.ContinueOnError, func(i int, fileSource string) error {
+		start := time.Now()
+		var err error
+		notifications[i], models[i], err = analyzeFile(p.envVariables, fileSource, p.insightsClient, p.outputWriter)
+		results[i] = newFileResult(fileSource, err, time.Since(start))
+		return err
+	})
+
+	var included []int
+	var digest notify.Digest
+	for i, result := range results {
+		if result.Status == StatusSucceeded {
+			included = append(included, i)
+			digest.Notifications = append(digest.Notifications, notifications[i])
+		}
+	}
+	if len(included) < len(files) && !p.envVariables.ContinueOnError {
+		for _, i := range included {
+			results[i] = FileResult{File: files[i], Status: StatusNotProcessed}
+		}
+		return results
+	}
+	if len(digest.Notifications) == 0 {
+		if len(files) == 0 {
+			log.Printf("No new or changed files in %s, skipping digest", p.envVariables.FileDirectory)
+		}
+		return results
+	}
+
+	start := time.Now()
+	err := deliverDigest(p.envVariables, digest, p.insightsClient, p.targets)
+	for _, i := range included {
+		result := newFileResult(files[i], err, results[i].Duration+time.Since(start))
+		if recordErr := p.record(files[i], hashes[files[i]], models[i], nil, err); recordErr != nil {
+			result = newFileResult(files[i], &StageError{Stage: StageLedger, Err: recordErr}, result.Duration)
+		}
+		results[i] = result
+	}
+	return results
+}
+
+// deliverDigest writes the cross-property summary of the digest and delivers it to every channel.
+func deliverDigest(envVariables common.EnvVariables, digest notify.Digest, insightsClient ai.Client, targets []notify.Target) error {
+	properties := make([]common.PropertyMetrics, 0, len(digest.Notifications))
+	for _, notification := range digest.Notifications {
+		properties = append(properties, notification.Property)
+	}
+	summary, err := digestSummary(envVariables.ApiKey, insightsClient, properties)
+	if err != nil {
+		return &StageError{Stage: StageInsights, Err: fmt.Errorf("error summarising digest: %v", err)}
+	}
+	digest.Summary = summary
+
+	err = notify.DispatchDigest(targets, digest)
+	if err != nil {
+		return &DeliveryError{Err: err}
+	}
+	return nil
+}
+
+// digestSummary asks the insights client for the cross-property summary, or the rule-based client if the insights
+// client cannot summarise digests.
+func digestSummary(apiKey string, insightsClient ai.Client, properties []common.PropertyMetrics) (string, error) {
+	if digestClient, ok := insightsClient.(ai.DigestClient); ok {
+		return digestClient.GetDigestSummary(apiKey, properties)
+	}
+	return ai.NewRuleBasedClient().GetDigestSummary(apiKey, properties)
+}
+
+// propertyName returns the name of the property a data file belongs to: its base name without the extensions.
+func propertyName(fileSource string) string {
+	return file.Name(fileSource)
+}
@@ -0,0 +1,73 @@
+package pkg
+
+import (
+	"data-insights/kit/ledger"
+	"data-insights/kit/notify"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// unprocessed returns the files that are not in the ledger as delivered with their current content, with their
+// content hashes. With FORCE every file is returned.
+func (p *Pipeline) unprocessed(files []string) ([]string, map[string]string, error) {
+	var pending []string
+	hashes := make(map[string]string, len(files))
+	for _, fileSource := range files {
+		hash, err := p.hash(fileSource)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error hashing file %s: %v", fileSource, err)
+		}
+		if !p.envVariables.Force && p.ledger.Processed(fileSource, hash) {
+			log.Printf("Skipping %s, it has not changed since it was delivered", fileSource)
+			continue
+		}
+		pending = append(pending, fileSource)
+		hashes[fileSource] = hash
+	}
+	return pending, hashes, nil
+}
+
+// hash returns the content hash of the file as stored, read through the source so that remote files are compared
+// like local ones.
+func (p *Pipeline) hash(fileSource string) (string, error) {
+	content, err := p.source.Open(fileSource)
+	if err != nil {
+		return "", err
+	}
+	defer content.Close()
+	return ledger.Hash(content)
+}
+
+// record adds the processed file to the ledger with the model that wrote its insights and the result of its
+// delivery. When the delivery failed, the email recipients that received the file are recorded too: those reported
+// by the email channel, or else the ones delivered before. Files whose processing failed before delivery are not
+// recorded, so that they are processed again by the next run. Nothing is recorded in a dry run.
+func (p *Pipeline) record(fileSource, hash, model string, delivered []string, err error) error {
+	var deliveryErr *DeliveryError
+	if p.envVariables.DryRun || (err != nil && !errors.As(err, &deliveryErr)) {
+		return nil
+	}
+
+	entry := ledger.Entry{
+		Path:        fileSource,
+		Hash:        hash,
+		ProcessedAt: time.Now().UTC(),
+		Model:       model,
+		Status:      ledger.StatusDelivered,
+	}
+	if err != nil {
+		entry.Status = ledger.StatusDeliveryFailed
+		entry.Error = err.Error()
+		entry.Delivered = delivered
+		var recipientsErr *notify.RecipientsError
+		if errors.As(err, &recipientsErr) {
+			entry.Delivered = recipientsErr.Delivered
+		}
+	}
+	if err := p.ledger.Record(entry); err != nil {
+		return fmt.Errorf("error recording %s in the ledger: %v", fileSource, err)
+	}
+	return nil
+}
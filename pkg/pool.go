@@ -0,0 +1,89 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// forEachFile calls process for every file, with up to concurrency files at a time, and returns the errors by file
+// index. With stopOnFailure no new files are started once a file fails, but the files in progress are finished;
+// the files that were not started have no error.
+func forEachFile(files []string, concurrency int, stopOnFailure bool, process func(i int, fileSource string) error) []error {
+	errs := make([]error, len(files))
+	workers := min(max(concurrency, 1), len(files))
+
+	var failed atomic.Bool
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				// A file may fail while the next index is being handed over, so check again before starting it
+				if stopOnFailure && failed.Load() {
+					continue
+				}
+				if errs[i] = process(i, files[i]); errs[i] != nil {
+					failed.Store(true)
+				}
+			}
+		}()
+	}
+	for i := range files {
+		if stopOnFailure && failed.Load() {
+			break
+		}
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	return errs
+}
+
+// fileErrors are the errors of several files, in the order of the files.
+type fileErrors []error
+
+func (e fileErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return fmt.Sprintf("%d files failed: %s", len(e), strings.Join(messages, "; "))
+}
+
+func (e fileErrors) Unwrap() []error {
+	return e
+}
+
+// joinFileErrors returns the errors of the files that failed, in the order of the files. The result is a
+// DeliveryError only if every failure is a delivery failure, so that a processing failure is never reported as a
+// delivery failure.
+func joinFileErrors(files []string, errs []error) error {
+	var failures fileErrors
+	deliveryOnly := true
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+		var deliveryErr *DeliveryError
+		if !errors.As(err, &deliveryErr) {
+			deliveryOnly = false
+		}
+		failures = append(failures, fmt.Errorf("error processing file %s: %w", files[i], err))
+	}
+
+	switch {
+	case len(failures) == 0:
+		return nil
+	case len(failures) == 1:
+		return failures[0]
+	case deliveryOnly:
+		return failures
+	default:
+		return errors.New(failures.Error())
+	}
+}
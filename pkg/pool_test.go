@@ -0,0 +1,81 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// failSlowly fails after a moment, so that the next file is already being handed to a worker when the failure is
+// recorded.
+func failSlowly() error {
+	time.Sleep(10 * time.Millisecond)
+	return errors.New("failed")
+}
+
+func TestForEachFileStopsOnFailure(t *testing.T) {
+	files := []string{"a.json", "b.json", "c.json", "d.json", "e.json"}
+	for _, concurrency := range []int{1, 2} {
+		t.Run(fmt.Sprintf("concurrency %d", concurrency), func(t *testing.T) {
+			var mu sync.Mutex
+			var started []int
+			// Every file fails, so each worker must stop after its first file
+			errs := forEachFile(files, concurrency, true, func(i int, fileSource string) error {
+				mu.Lock()
+				started = append(started, i)
+				mu.Unlock()
+				return failSlowly()
+			})
+
+			if len(started) == 0 || len(started) > concurrency {
+				t.Fatalf("started files %v, want between 1 and %d", started, concurrency)
+			}
+			failures := 0
+			for _, err := range errs {
+				if err != nil {
+					failures++
+				}
+			}
+			if failures != len(started) {
+				t.Errorf("got %d errors for %d started files", failures, len(started))
+			}
+		})
+	}
+}
+
+func TestForEachFileStopsAfterFirstFailureInOrder(t *testing.T) {
+	files := []string{"a.json", "b.json", "c.json"}
+	var started []int
+	errs := forEachFile(files, 1, true, func(i int, fileSource string) error {
+		started = append(started, i)
+		if i == 0 {
+			return failSlowly()
+		}
+		return nil
+	})
+
+	if len(started) != 1 || started[0] != 0 {
+		t.Fatalf("started files %v, want only the first", started)
+	}
+	if errs[0] == nil || errs[1] != nil || errs[2] != nil {
+		t.Errorf("got errors %v, want only the first file to fail", errs)
+	}
+}
+
+func TestForEachFileContinuesOnFailure(t *testing.T) {
+	files := []string{"a.json", "b.json", "c.json", "d.json"}
+	for _, concurrency := range []int{1, 2} {
+		t.Run(fmt.Sprintf("concurrency %d", concurrency), func(t *testing.T) {
+			errs := forEachFile(files, concurrency, false, func(i int, fileSource string) error {
+				return fmt.Errorf("failed %s", fileSource)
+			})
+			for i, err := range errs {
+				if err == nil {
+					t.Errorf("file %s was not processed", files[i])
+				}
+			}
+		})
+	}
+}
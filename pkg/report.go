@@ -0,0 +1,116 @@
+package pkg
+
+import (
+	"data-insights/kit/common"
+	"data-insights/kit/file"
+	"data-insights/kit/metrics"
+	"data-insights/kit/notify"
+	"data-insights/kit/report"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+)
+
+// DeliveryError reports that insights were generated and stored but could not be delivered to every channel.
+type DeliveryError struct {
+	Err error
+}
+
+func (e *DeliveryError) Error() string {
+	return fmt.Sprintf("error delivering insights: %v", e.Err)
+}
+
+func (e *DeliveryError) Unwrap() error {
+	return e.Err
+}
+
+// RenderReport writes a stored report to disk in the configured output formats and as a PDF if PDF_DIR is set,
+// without recomputing anything. Returns an error if nothing is configured to be written.
+func RenderReport(envVariables common.EnvVariables, insightsReport report.Report) error {
+	renderer, err := newRenderer(envVariables)
+	if err != nil {
+		return fmt.Errorf("error loading templates: %s", err)
+	}
+	outputWriter, err := newOutputWriter(envVariables, renderer)
+	if err != nil {
+		return fmt.Errorf("error setting up outputs: %s", err)
+	}
+	if outputWriter == nil && envVariables.PdfDirectory == "" {
+		return errors.New("no output formats or PDF directory configured")
+	}
+
+	// Only the charts are rendered again; PDF attachments are not needed
+	envVariables.PdfAttachment = false
+	_, err = buildNotification(envVariables, insightsReport, dailyMetrics(envVariables, insightsReport), outputWriter)
+	return err
+}
+
+// SendReport delivers a stored report to every configured channel without recomputing anything.
+func SendReport(envVariables common.EnvVariables, insightsReport report.Report) error {
+	renderer, err := newRenderer(envVariables)
+	if err != nil {
+		return fmt.Errorf("error loading templates: %s", err)
+	}
+	targets, err := newTargets(envVariables, renderer)
+	if err != nil {
+		return fmt.Errorf("error setting up channels: %s", err)
+	}
+	if len(targets) == 0 {
+		return errors.New("no channels configured")
+	}
+
+	notification, err := buildNotification(envVariables, insightsReport, dailyMetrics(envVariables, insightsReport), nil)
+	if err != nil {
+		return err
+	}
+	if err := notify.Dispatch(targets, notification); err != nil {
+		return &DeliveryError{Err: err}
+	}
+	return nil
+}
+
+// ValidateConfig checks everything a run depends on without processing any files or sending anything: the data
+// directory, the insight engine, the templates and theme, the channels and recipients and the output settings.
+// It returns every problem found.
+func ValidateConfig(envVariables common.EnvVariables) error {
+	var errs []error
+	if file.IsRemote(envVariables.FileDirectory) {
+		if _, err := DataSource(envVariables).List(envVariables.FileDirectory, WalkOptions(envVariables)); err != nil {
+			errs = append(errs, fmt.Errorf("FILE_DIR: %v", err))
+		}
+	} else if info, err := os.Stat(envVariables.FileDirectory); err != nil {
+		errs = append(errs, fmt.Errorf("FILE_DIR: %v", err))
+	} else if !info.IsDir() {
+		errs = append(errs, fmt.Errorf("FILE_DIR: %s is not a directory", envVariables.FileDirectory))
+	}
+
+	// The channels and outputs are checked even if the templates are invalid; they only keep the renderer
+	renderer, err := newRenderer(envVariables)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("templates: %v", err))
+	}
+	targets, targetsErr := newTargets(envVariables, renderer)
+	if targetsErr != nil {
+		errs = append(errs, fmt.Errorf("channels: %v", targetsErr))
+	}
+	outputWriter, outputErr := newOutputWriter(envVariables, renderer)
+	if outputErr != nil {
+		errs = append(errs, fmt.Errorf("outputs: %v", outputErr))
+	}
+	if targetsErr == nil && outputErr == nil && len(targets) == 0 && outputWriter == nil {
+		errs = append(errs, errors.New("no channels or output formats configured"))
+	}
+	return errors.Join(errs...)
+}
+
+// dailyMetrics recomputes the daily metrics of a stored report from its source file, for the daily sessions
+// chart. The chart is left out if the source file can no longer be read.
+func dailyMetrics(envVariables common.EnvVariables, insightsReport report.Report) []common.DailyMetrics {
+	data, err := insightsReport.SourceData(DataSource(envVariables))
+	if err != nil {
+		log.Printf("Source file of report %s is not available, skipping the daily sessions chart: %v", insightsReport.ID, err)
+		return nil
+	}
+	return metrics.CalculateDailyMetrics(data)
+}
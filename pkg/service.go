@@ -2,74 +2,311 @@ package pkg
 
 import (
 	"data-insights/kit/ai"
+	"data-insights/kit/chart"
 	"data-insights/kit/common"
 	"data-insights/kit/email"
 	"data-insights/kit/file"
+	"data-insights/kit/ledger"
 	"data-insights/kit/metrics"
+	"data-insights/kit/notify"
+	"data-insights/kit/output"
+	"data-insights/kit/pdf"
+	"data-insights/kit/report"
 	"encoding/json"
 	"fmt"
 	"log"
+	"os"
 	"path/filepath"
+	"strings"
+	"time"
 )
 
 // ProcessFiles iterates over all files in the specified directory, processes each file to generate insights,
-// and sends an email with the insights. Returns an error if any step fails.
-func ProcessFiles(envVariables common.EnvVariables, openAiClient ai.OpenAIClient) error {
+// and delivers the insights to every configured channel, each file on its own or together as a digest. Files that
+// have not changed since they were processed are skipped, unless FORCE is set. The failures are emailed to
+// OPERATOR_EMAIL if it is set. Returns the result of every file, and an error if any file fails.
+func ProcessFiles(envVariables common.EnvVariables, insightsClient ai.Client) (RunSummary, error) {
+	pipeline, err := NewPipeline(envVariables, insightsClient)
+	if err != nil {
+		return RunSummary{}, err
+	}
+	operator, err := newOperatorEmailService(envVariables)
+	if err != nil {
+		return RunSummary{}, fmt.Errorf("error setting up the operator email: %s", err)
+	}
 
-	files, err := file.FilePathWalkDir(envVariables.FileDirectory)
+	files, err := DataSource(envVariables).List(envVariables.FileDirectory, WalkOptions(envVariables))
 	if err != nil {
-		return fmt.Errorf("error loading files: %s from directory: %s", err, envVariables.FileDirectory)
+		return RunSummary{}, fmt.Errorf("error loading files: %s from directory: %s", err, envVariables.FileDirectory)
+	}
+	summary, err := pipeline.Process(files)
+	if operator != nil {
+		if notifyErr := notifyOperator(operator, envVariables.OperatorEmail, summary); notifyErr != nil {
+			log.Printf("Error emailing the failures to %s: %s", envVariables.OperatorEmail, notifyErr)
+		}
 	}
+	return summary, err
+}
 
-	for _, fileSource := range files {
-		err = processFile(envVariables, fileSource, openAiClient)
-		if err != nil {
-			return fmt.Errorf("error processing file %s: %s", fileSource, err)
+// WalkOptions returns the filters that select the data files in FILE_DIR. The patterns and the symlink policy are
+// validated when the settings are read.
+func WalkOptions(envVariables common.EnvVariables) file.WalkOptions {
+	include, _ := file.ParsePatterns(envVariables.FileInclude)
+	exclude, _ := file.ParsePatterns(envVariables.FileExclude)
+	symlinks, _ := file.ParseSymlinkPolicy(envVariables.FileSymlinks)
+	return file.WalkOptions{
+		Include:       include,
+		Exclude:       exclude,
+		Extensions:    file.ParseExtensions(envVariables.FileExtensions),
+		MaxDepth:      envVariables.FileMaxDepth,
+		Symlinks:      symlinks,
+		IncludeHidden: envVariables.FileHidden,
+		ModifiedSince: envVariables.FileModifiedSince,
+	}
+}
+
+// DataSource returns the source that reads the data files from the local filesystem, from S3-compatible object
+// storage with the S3_* settings, or over HTTP with the HTTP_HEADERS, depending on the location.
+func DataSource(envVariables common.EnvVariables) file.Source {
+	return file.NewSource(file.SourceOptions{
+		S3: file.S3Config{
+			Endpoint:        envVariables.S3Endpoint,
+			Region:          envVariables.S3Region,
+			AccessKeyID:     envVariables.S3AccessKeyID,
+			SecretAccessKey: envVariables.S3SecretAccessKey,
+		},
+		HTTPHeaders: envVariables.HTTPHeaders,
+	})
+}
+
+// Pipeline is the delivery set up for a run: the source of the data files, the parsed templates, the channels, the
+// report file writer and the ledger of processed files. It is set up once and can process files any number of times.
+type Pipeline struct {
+	envVariables   common.EnvVariables
+	insightsClient ai.Client
+	source         file.Source
+	targets        []notify.Target
+	outputWriter   *output.Writer
+	ledger         *ledger.Ledger
+}
+
+// NewPipeline parses the templates, sets up the channels and outputs and loads the ledger. Returns an error if
+// any of them is invalid, or if there is nothing to deliver to.
+func NewPipeline(envVariables common.EnvVariables, insightsClient ai.Client) (*Pipeline, error) {
+
+	renderer, err := newRenderer(envVariables)
+	if err != nil {
+		return nil, fmt.Errorf("error loading templates: %s", err)
+	}
+
+	targets, err := newTargets(envVariables, renderer)
+	if err != nil {
+		return nil, fmt.Errorf("error setting up channels: %s", err)
+	}
+
+	outputWriter, err := newOutputWriter(envVariables, renderer)
+	if err != nil {
+		return nil, fmt.Errorf("error setting up outputs: %s", err)
+	}
+	if len(targets) == 0 && outputWriter == nil {
+		return nil, fmt.Errorf("no channels or output formats configured")
+	}
+
+	processed, err := ledger.Load(envVariables.LedgerFile)
+	if err != nil {
+		return nil, fmt.Errorf("error loading ledger: %s", err)
+	}
+
+	return &Pipeline{envVariables: envVariables, insightsClient: insightsClient, source: DataSource(envVariables), targets: targets, outputWriter: outputWriter, ledger: processed}, nil
+}
+
+// Process generates and delivers the insights of the files that have changed since they were processed, up to
+// CONCURRENCY files at a time, each on its own or together as a digest, and records them in the ledger. Once a
+// file fails no new files are started, unless CONTINUE_ON_ERROR is set. Returns the result of every file, and the
+// errors of the failed files in the order of the files.
+func (p *Pipeline) Process(files []string) (RunSummary, error) {
+	pending, hashes, err := p.unprocessed(files)
+	if err != nil {
+		return RunSummary{}, err
+	}
+	if p.envVariables.Digest {
+		summary := newRunSummary(files, pending, p.processDigest(pending, hashes))
+		return summary, summary.Err()
+	}
+
+	results := make([]FileResult, len(pending))
+	forEachFile(pending, p.envVariables.Concurrency, !p.envVariables.ContinueOnError, func(i int, fileSource string) error {
+		start := time.Now()
+		delivered := p.ledger.Delivered(fileSource, hashes[fileSource])
+		model, err := processFile(p.envVariables, fileSource, delivered, p.insightsClient, p.targets, p.outputWriter)
+		if recordErr := p.record(fileSource, hashes[fileSource], model, delivered, err); recordErr != nil {
+			err = &StageError{Stage: StageLedger, Err: recordErr}
 		}
-		log.Printf("Email sent successfully, insights from the file %s has been sent to %s", fileSource, envVariables.EmailTo)
+		results[i] = newFileResult(fileSource, err, time.Since(start))
+		return err
+	})
+	summary := newRunSummary(files, pending, results)
+	return summary, summary.Err()
+}
+
+// processFile handles the processing of a single file and delivers the insights to every channel that receives
+// the file, skipping the email recipients that have already received it. Returns the model that wrote the
+// insights, and an error if any step fails.
+func processFile(envVariables common.EnvVariables, fileSource string, delivered []string, insightsClient ai.Client, targets []notify.Target, outputWriter *output.Writer) (string, error) {
+	notification, model, err := analyzeFile(envVariables, fileSource, insightsClient, outputWriter)
+	if err != nil {
+		return model, err
 	}
-	return nil
+	notification.Delivered = delivered
+
+	err = notify.Dispatch(targets, notification)
+	if err != nil {
+		return model, &DeliveryError{Err: err}
+	}
+	return model, nil
+}
+
+// analyzeFile generates and stores the report for the file, writes the report files and the PDF, and returns the
+// notification to deliver together with the model that wrote the insights. Returns an error if any step fails.
+func analyzeFile(envVariables common.EnvVariables, fileSource string, insightsClient ai.Client, outputWriter *output.Writer) (notify.Notification, string, error) {
+	insightsReport, data, err := GenerateReport(envVariables, fileSource, insightsClient)
+	if err != nil {
+		return notify.Notification{}, "", err
+	}
+	notification, err := buildNotification(envVariables, insightsReport, metrics.CalculateDailyMetrics(data), outputWriter)
+	if err != nil {
+		return notify.Notification{}, insightsReport.Model, &StageError{Stage: StageOutput, Err: err}
+	}
+	return notification, insightsReport.Model, nil
 }
 
-// processFile handles the processing of a single file. It reads the raw data from the file,
-// generates insights using OpenAI, unmarshalls the results, renders an email template with the insights,
-// and sends the email. Returns an error if any step fails.
-func processFile(envVariables common.EnvVariables, fileSource string, openAiClient ai.OpenAIClient) error {
+// GenerateReport reads the raw data from the file, generates insights using the insights client, unmarshalls the
+// results and stores them as a report. It returns the report together with the raw data. Returns an error if any
+// step fails.
+func GenerateReport(envVariables common.EnvVariables, fileSource string, insightsClient ai.Client) (report.Report, []common.Insight, error) {
 
-	data, err := file.GetRawDataFromFile(fileSource)
+	data, sourceHash, err := file.GetRawDataWithHash(DataSource(envVariables), fileSource)
 	if err != nil {
-		return fmt.Errorf("error while getting raw data from file %s: %v", fileSource, err)
+		return report.Report{}, nil, &StageError{Stage: StageRead, Err: fmt.Errorf("error while getting raw data from file %s: %v", fileSource, err)}
 	}
 
-	insights, err := openAiClient.GetInsightsFromLLM(envVariables.ApiKey, metrics.CalculateKeyMetrics(data))
+	userMetrics := KeyMetrics(envVariables, data)
+	generation, err := ai.Generate(insightsClient, envVariables.ApiKey, userMetrics, ai.NewMetricsToolbox(data))
 	if err != nil {
-		return fmt.Errorf("error getting insights from LLM: %v", err)
+		return report.Report{}, nil, &StageError{Stage: StageInsights, Err: fmt.Errorf("error getting insights from LLM: %v", err)}
 	}
 
 	var userMetricsWithInsights common.UserMetricsWithInsights
-	err = json.Unmarshal([]byte(insights), &userMetricsWithInsights)
+	err = json.Unmarshal([]byte(generation.Insights), &userMetricsWithInsights)
+	if err != nil {
+		return report.Report{}, nil, &StageError{Stage: StageInsights, Err: fmt.Errorf("error unmarshalling user metrics with insights from LLM: %v", err)}
+	}
+	userMetricsWithInsights.ExecutiveSummary = ai.VerifyExecutiveSummary(userMetricsWithInsights.ExecutiveSummary, userMetrics)
+
+	// Store the report so that follow-up questions can be asked about it
+	insightsReport := report.NewReport(fileSource, userMetrics, userMetricsWithInsights, generation.ToolCalls)
+	insightsReport.SourceHash = sourceHash
+	insightsReport.Model = generation.Model
+	err = report.NewStore(envVariables.ReportDirectory).Save(insightsReport)
 	if err != nil {
-		return fmt.Errorf("error unmarshalling user metrics with insights from LLM: %v", err)
+		return report.Report{}, nil, &StageError{Stage: StageReport, Err: fmt.Errorf("error saving report: %v", err)}
 	}
+	return insightsReport, data, nil
+}
 
-	emailService := email.NewSMTPEmailService(envVariables.SmtpHost, envVariables.SmtpPort, envVariables.EmailFrom, envVariables.EmailPass)
+// buildNotification renders the report's charts, writes the report files and the PDF, and returns the
+// notification to deliver. The daily metrics are used for the daily sessions chart and may be empty.
+func buildNotification(envVariables common.EnvVariables, insightsReport report.Report, daily []common.DailyMetrics, outputWriter *output.Writer) (notify.Notification, error) {
+	fileSource := insightsReport.SourceFile
+	charts, err := chart.ReportCharts(insightsReport.Metrics, daily, chart.Format(envVariables.ChartFormat))
+	if err != nil {
+		return notify.Notification{}, fmt.Errorf("error rendering charts: %v", err)
+	}
 
-	// Set up the renderer
-	renderer := email.NewRenderer(filepath.Join(email.TemplateDir, email.TemplateFile))
-	// Render the email body
-	body, err := renderer.Render(common.EmailData{
-		RecipientName:           envVariables.RecipientName,
-		UserMetricsWithInsights: userMetricsWithInsights,
-	})
+	if outputWriter != nil {
+		paths, err := outputWriter.Write(insightsReport, charts)
+		if err != nil {
+			return notify.Notification{}, fmt.Errorf("error writing report files: %v", err)
+		}
+		log.Printf("Report for the file %s written to %s", fileSource, strings.Join(paths, ", "))
+	}
+
+	attachments, err := exportPDF(envVariables, insightsReport, charts, daily)
 	if err != nil {
-		return fmt.Errorf("error rendering email template: %v", err)
+		return notify.Notification{}, fmt.Errorf("error exporting PDF: %v", err)
+	}
+
+	return notify.Notification{
+		SourceFile:  fileSource,
+		Insights:    insightsReport.Insights,
+		ToolCalls:   insightsReport.ToolCalls,
+		Charts:      charts,
+		Attachments: attachments,
+		Property:    common.PropertyMetrics{Name: propertyName(fileSource), OverallMetrics: insightsReport.Metrics.OverallMetrics},
+	}, nil
+}
+
+// KeyMetrics calculates the key metrics of the data with the MIN_DATA_POINTS threshold if one is set.
+func KeyMetrics(envVariables common.EnvVariables, data []common.Insight) common.UserMetrics {
+	if envVariables.MinDataPoints > 0 {
+		return metrics.CalculateKeyMetricsWithThreshold(data, envVariables.MinDataPoints)
+	}
+	return metrics.CalculateKeyMetrics(data)
+}
+
+// exportPDF renders the report as a PDF when it is written to PDF_DIR or attached to emails, and returns the
+// email attachments. The PDF always has PNG charts, so they are rendered again when another format is configured.
+func exportPDF(envVariables common.EnvVariables, insightsReport report.Report, charts []chart.Chart, daily []common.DailyMetrics) ([]email.Attachment, error) {
+	if !envVariables.PdfAttachment && envVariables.PdfDirectory == "" {
+		return nil, nil
 	}
 
-	// Send the email
-	err = emailService.SendEmail(envVariables.EmailTo, email.SubjectName, body)
+	if chart.Format(envVariables.ChartFormat) == chart.FormatSVG {
+		var err error
+		if charts, err = chart.ReportCharts(insightsReport.Metrics, daily, chart.FormatPNG); err != nil {
+			return nil, fmt.Errorf("error rendering charts: %v", err)
+		}
+	}
+	document, err := pdf.Render(insightsReport, nil, charts)
 	if err != nil {
-		return fmt.Errorf("error sending email to %s: %v", envVariables.EmailTo, err)
+		return nil, err
+	}
+
+	filename := insightsReport.ID + pdf.Extension
+	if envVariables.PdfDirectory != "" {
+		if err := os.MkdirAll(envVariables.PdfDirectory, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create PDF directory: %v", err)
+		}
+		path := filepath.Join(envVariables.PdfDirectory, filename)
+		if err := os.WriteFile(path, document, 0o644); err != nil {
+			return nil, fmt.Errorf("failed to write PDF: %v", err)
+		}
+		log.Printf("PDF report written to %s", path)
+	}
+
+	if !envVariables.PdfAttachment {
+		return nil, nil
+	}
+	return []email.Attachment{{Filename: filename, ContentType: pdf.ContentType, Data: document}}, nil
+}
+
+// newOutputWriter returns a writer for the configured output formats, or nil if no report files are written.
+func newOutputWriter(envVariables common.EnvVariables, renderer email.Renderer) (*output.Writer, error) {
+	formats, err := output.ParseFormats(envVariables.OutputFormats)
+	if err != nil || len(formats) == 0 {
+		return nil, err
 	}
+	return output.NewWriter(formats, envVariables.OutputPath, renderer)
+}
 
-	return nil
+// newRenderer parses the report templates once, with the theme file if one is configured.
+func newRenderer(envVariables common.EnvVariables) (*email.EmailRenderer, error) {
+	theme := email.DefaultTheme()
+	if envVariables.ThemeFile != "" {
+		var err error
+		if theme, err = email.LoadTheme(envVariables.ThemeFile); err != nil {
+			return nil, err
+		}
+	}
+	return email.NewRenderer(envVariables.TemplateDirectory, theme)
 }
@@ -0,0 +1,211 @@
+package pkg
+
+import (
+	"data-insights/kit/common"
+	"data-insights/kit/email"
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Stage is the step of processing a file that failed.
+type Stage string
+
+const (
+	StageRead     Stage = "read"     // reading and parsing the data file
+	StageInsights Stage = "insights" // generating the insights
+	StageReport   Stage = "report"   // storing the report
+	StageOutput   Stage = "output"   // rendering the charts and writing the report files and the PDF
+	StageDelivery Stage = "delivery" // delivering to the channels
+	StageLedger   Stage = "ledger"   // recording the file in the ledger
+)
+
+// ResultStatus is the outcome of processing a file.
+type ResultStatus string
+
+const (
+	StatusSucceeded    ResultStatus = "succeeded"
+	StatusFailed       ResultStatus = "failed"
+	StatusSkipped      ResultStatus = "skipped"       // unchanged since it was processed
+	StatusNotProcessed ResultStatus = "not processed" // not started, or left out of a digest, after another file failed
+)
+
+// StageError reports the stage at which processing a file failed.
+type StageError struct {
+	Stage Stage
+	Err   error
+}
+
+func (e *StageError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *StageError) Unwrap() error {
+	return e.Err
+}
+
+// FileResult is the result of processing one data file. Stage and Err are set when it failed.
+type FileResult struct {
+	File     string
+	Status   ResultStatus
+	Stage    Stage
+	Err      error
+	Duration time.Duration
+}
+
+// newFileResult returns the result of a file that was processed in duration, with the stage at which it failed
+// if err is set.
+func newFileResult(fileSource string, err error, duration time.Duration) FileResult {
+	if err == nil {
+		return FileResult{File: fileSource, Status: StatusSucceeded, Duration: duration}
+	}
+	return FileResult{File: fileSource, Status: StatusFailed, Stage: failedStage(err), Err: err, Duration: duration}
+}
+
+// failedStage returns the stage at which err occurred, or an empty stage if it is not known.
+func failedStage(err error) Stage {
+	var deliveryErr *DeliveryError
+	if errors.As(err, &deliveryErr) {
+		return StageDelivery
+	}
+	var stageErr *StageError
+	if errors.As(err, &stageErr) {
+		return stageErr.Stage
+	}
+	return ""
+}
+
+// RunSummary is the result of every data file of a run, in the order of the files.
+type RunSummary struct {
+	Results []FileResult
+}
+
+// newRunSummary returns the summary of the files, where results holds the results of the pending files by index.
+// The other files were skipped, and pending files without a result were not processed.
+func newRunSummary(files, pending []string, results []FileResult) RunSummary {
+	byFile := make(map[string]FileResult, len(pending))
+	for i, fileSource := range pending {
+		result := results[i]
+		if result.Status == "" {
+			result = FileResult{File: fileSource, Status: StatusNotProcessed}
+		}
+		byFile[fileSource] = result
+	}
+
+	summary := RunSummary{Results: make([]FileResult, 0, len(files))}
+	for _, fileSource := range files {
+		result, ok := byFile[fileSource]
+		if !ok {
+			result = FileResult{File: fileSource, Status: StatusSkipped}
+		}
+		summary.Results = append(summary.Results, result)
+	}
+	return summary
+}
+
+// Count returns the number of files with the status.
+func (s RunSummary) Count(status ResultStatus) int {
+	count := 0
+	for _, result := range s.Results {
+		if result.Status == status {
+			count++
+		}
+	}
+	return count
+}
+
+// Failures returns the results of the files that failed.
+func (s RunSummary) Failures() []FileResult {
+	var failures []FileResult
+	for _, result := range s.Results {
+		if result.Status == StatusFailed {
+			failures = append(failures, result)
+		}
+	}
+	return failures
+}
+
+// Err returns the errors of the files that failed, in the order of the files, or nil if none failed. It is a
+// DeliveryError only if every failure is a delivery failure.
+func (s RunSummary) Err() error {
+	files := make([]string, len(s.Results))
+	errs := make([]error, len(s.Results))
+	for i, result := range s.Results {
+		files[i], errs[i] = result.File, result.Err
+	}
+	return joinFileErrors(files, errs)
+}
+
+// Totals returns a one-line count of the files by status.
+func (s RunSummary) Totals() string {
+	counts := []string{
+		fmt.Sprintf("%d succeeded", s.Count(StatusSucceeded)),
+		fmt.Sprintf("%d failed", s.Count(StatusFailed)),
+	}
+	if skipped := s.Count(StatusSkipped); skipped > 0 {
+		counts = append(counts, fmt.Sprintf("%d skipped", skipped))
+	}
+	if notProcessed := s.Count(StatusNotProcessed); notProcessed > 0 {
+		counts = append(counts, fmt.Sprintf("%d not processed", notProcessed))
+	}
+	return fmt.Sprintf("%d files: %s", len(s.Results), strings.Join(counts, ", "))
+}
+
+// Table returns the results as a table with a row per file, followed by the totals.
+func (s RunSummary) Table() string {
+	return resultsTable(s.Results) + s.Totals() + "\n"
+}
+
+// resultsTable formats the results as a table with the file, its status, the stage that failed, the time it took
+// and the error.
+func resultsTable(results []FileResult) string {
+	var table strings.Builder
+	writer := tabwriter.NewWriter(&table, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "FILE\tSTATUS\tSTAGE\tDURATION\tERROR")
+	for _, result := range results {
+		duration, message := "", ""
+		if result.Duration > 0 {
+			duration = result.Duration.Round(time.Millisecond).String()
+		}
+		if result.Err != nil {
+			message = result.Err.Error()
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\n", result.File, result.Status, result.Stage, duration, message)
+	}
+	writer.Flush()
+	return table.String()
+}
+
+// newOperatorEmailService returns the email service for the failure emails to OPERATOR_EMAIL, or nil if it is not
+// set. Emails are queued in the outbox, or written to the dry run directory in a dry run.
+func newOperatorEmailService(envVariables common.EnvVariables) (email.EmailService, error) {
+	if envVariables.OperatorEmail == "" {
+		return nil, nil
+	}
+	if envVariables.DryRun {
+		if envVariables.EmailFrom == "" {
+			return nil, errors.New("EMAIL_FROM is required for the operator email")
+		}
+		return email.NewFileEmailService(envVariables.DryRunDirectory, envVariables.EmailFrom), nil
+	}
+	return NewOutbox(envVariables)
+}
+
+// notifyOperator emails the failed files of the run to the operator, with the stage at which each one failed and
+// its error. Nothing is sent if no file failed.
+func notifyOperator(emailService email.EmailService, operator string, summary RunSummary) error {
+	failures := summary.Failures()
+	if len(failures) == 0 {
+		return nil
+	}
+	text := fmt.Sprintf("%s\n\n%s", summary.Totals(), resultsTable(failures))
+	return emailService.SendEmail(email.Message{
+		To:      []string{operator},
+		Subject: email.FailureSubjectName,
+		Body:    fmt.Sprintf("<p>%s</p>\n<pre>%s</pre>\n", html.EscapeString(summary.Totals()), html.EscapeString(resultsTable(failures))),
+		Text:    text,
+	})
+}